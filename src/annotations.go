@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// annotationPathPatterns capture file paths kustomize mentions in its errors,
+	// e.g. "accumulating resources from 'deploy.yaml'", "in File: patch.yaml" or
+	// "evalsymlink failure on '/work/apps/web/missing.yaml'".
+	annotationPathPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`'([^'\s]+\.(?:ya?ml|json))'`),
+		regexp.MustCompile(`"([^"\s]+\.(?:ya?ml|json))"`),
+		regexp.MustCompile(`[Ff]ile:? ([^\s'":,]+\.(?:ya?ml|json))`),
+	}
+	annotationLinePattern = regexp.MustCompile(`\bline (\d+)`)
+)
+
+// annotation is a GitHub workflow command pointing at a file (and line) in the repository.
+type annotation struct {
+	File    string
+	Line    int
+	Title   string
+	Message string
+}
+
+func (a annotation) String() string {
+	props := "file=" + escapeAnnotationProperty(a.File)
+	if a.Line > 0 {
+		props += ",line=" + strconv.Itoa(a.Line)
+	}
+	if a.Title != "" {
+		props += ",title=" + escapeAnnotationProperty(a.Title)
+	}
+	return "::error " + props + "::" + escapeAnnotationData(a.Message)
+}
+
+// buildFailureAnnotation derives an annotation from a failed or timed-out root.
+// The offending file is taken from the kustomize error when it names one that
+// exists in the repository; otherwise the root's kustomization file is used.
+func buildFailureAnnotation(r RootResult) annotation {
+	dir := r.Root
+	if dir == "" {
+		dir = "."
+	}
+	a := annotation{
+		Title:   fmt.Sprintf("kustomize build %s: %s", strings.ReplaceAll(r.Status, "_", " "), displayRoot(r.Root)),
+		Message: annotationMessage(r),
+	}
+
+	if file, at := errorFileFromStderr(dir, r.Stderr); file != "" {
+		a.File = file
+		a.Line = errorLineFromStderr(stderrLineAt(r.Stderr, at))
+		return a
+	}
+	a.File = rootKustomizationFile(r.Root)
+	// A line number next to a mention of the kustomization refers to it.
+	for _, line := range strings.Split(r.Stderr, "\n") {
+		if strings.Contains(strings.ToLower(line), "kustomization") {
+			if n := errorLineFromStderr(line); n > 0 {
+				a.Line = n
+				break
+			}
+		}
+	}
+	return a
+}
+
+// errorFileFromStderr returns the repo-relative path of the last existing file
+// mentioned in stderr and the offset of that mention. Relative paths are
+// resolved against dir, the root being built.
+func errorFileFromStderr(dir, stderr string) (string, int) {
+	cwd, _ := os.Getwd()
+	found, foundAt := "", -1
+	for _, re := range annotationPathPatterns {
+		for _, m := range re.FindAllStringSubmatchIndex(stderr, -1) {
+			candidate := stderr[m[2]:m[3]]
+			var rel string
+			if filepath.IsAbs(candidate) {
+				r, err := filepath.Rel(cwd, candidate)
+				if err != nil {
+					continue
+				}
+				rel = r
+			} else {
+				rel = filepath.Join(dir, candidate)
+			}
+			rel = filepath.ToSlash(filepath.Clean(rel))
+			if rel == ".." || strings.HasPrefix(rel, "../") {
+				continue
+			}
+			if info, err := os.Stat(rel); err == nil && !info.IsDir() && m[2] > foundAt {
+				found, foundAt = rel, m[2]
+			}
+		}
+	}
+	return found, foundAt
+}
+
+// stderrLineAt returns the line of stderr containing offset at.
+func stderrLineAt(stderr string, at int) string {
+	start := strings.LastIndex(stderr[:at], "\n") + 1
+	if end := strings.Index(stderr[at:], "\n"); end >= 0 {
+		return stderr[start : at+end]
+	}
+	return stderr[start:]
+}
+
+// errorLineFromStderr returns the first "line N" of stderr, or 0.
+func errorLineFromStderr(stderr string) int {
+	m := annotationLinePattern.FindStringSubmatch(stderr)
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+// annotationMessage picks the most telling stderr line: the first "Error:" line,
+// else the last non-empty one.
+func annotationMessage(r RootResult) string {
+	var last string
+	for _, line := range strings.Split(r.Stderr, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "Error:") {
+			return line
+		}
+		last = line
+	}
+	if last != "" {
+		return last
+	}
+	if r.Status == statusTimedOut {
+		return "kustomize build timed out"
+	}
+	return "kustomize build failed"
+}
+
+// emitBuildAnnotations writes one ::error annotation per failed or timed-out
+// root to w, stopping after max annotations since GitHub drops the rest.
+func emitBuildAnnotations(w io.Writer, results []RootResult, max int) {
+	var failures []RootResult
+	for _, r := range results {
+		if r.Status == statusFailed || r.Status == statusTimedOut {
+			failures = append(failures, r)
+		}
+	}
+	for i, r := range failures {
+		if i >= max {
+			log.Printf("ℹ️ %d more failed roots not annotated (max-annotations=%d).", len(failures)-max, max)
+			return
+		}
+		fmt.Fprintln(w, buildFailureAnnotation(r))
+	}
+}
+
+// escapeAnnotationData escapes a workflow command message.
+func escapeAnnotationData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeAnnotationProperty escapes a workflow command property value.
+func escapeAnnotationProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
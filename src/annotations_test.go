@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	cwd, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(cwd) })
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	return tmpDir
+}
+
+func TestBuildFailureAnnotation_UsesFileAndLineFromStderr(t *testing.T) {
+	chdirTemp(t)
+	mustWriteFile(t, filepath.Join("apps", "web", "kustomization.yaml"), "resources:\n- deploy.yaml\n")
+	mustWriteFile(t, filepath.Join("apps", "web", "deploy.yaml"), "kind: [\n")
+
+	a := buildFailureAnnotation(RootResult{
+		Root:   "apps/web",
+		Status: statusFailed,
+		Stderr: "Error: accumulating resources: accumulation err='accumulating resources from 'deploy.yaml': MalformedYAMLError: yaml: line 3: did not find expected node content in File: deploy.yaml'\n",
+	})
+
+	if a.File != "apps/web/deploy.yaml" {
+		t.Errorf("expected file apps/web/deploy.yaml, got %q", a.File)
+	}
+	if a.Line != 3 {
+		t.Errorf("expected line 3, got %d", a.Line)
+	}
+	if !strings.HasPrefix(a.Message, "Error: accumulating resources") {
+		t.Errorf("unexpected message %q", a.Message)
+	}
+}
+
+func TestBuildFailureAnnotation_IgnoresLineOfOtherFiles(t *testing.T) {
+	chdirTemp(t)
+	mustWriteFile(t, filepath.Join("app", "kustomization.yaml"), "resources:\n- deploy.yaml\n")
+	mustWriteFile(t, filepath.Join("app", "deploy.yaml"), "kind: Deployment\n")
+
+	a := buildFailureAnnotation(RootResult{
+		Root:   "app",
+		Status: statusFailed,
+		Stderr: "warning: yaml: line 7: in the helm chart values\nError: could not merge 'deploy.yaml': conflicting patches\n",
+	})
+	if a.File != "app/deploy.yaml" {
+		t.Errorf("expected app/deploy.yaml, got %q", a.File)
+	}
+	if a.Line != 0 {
+		t.Errorf("expected no line for a number on another stderr line, got %d", a.Line)
+	}
+}
+
+func TestBuildFailureAnnotation_ResolvesAbsolutePaths(t *testing.T) {
+	tmpDir := chdirTemp(t)
+	mustWriteFile(t, filepath.Join("base", "kustomization.yaml"), "resources: []\n")
+	mustWriteFile(t, filepath.Join("base", "cm.yaml"), "kind: ConfigMap\n")
+	abs := filepath.Join(tmpDir, "base", "cm.yaml")
+
+	a := buildFailureAnnotation(RootResult{
+		Root:   "base",
+		Status: statusFailed,
+		Stderr: "Error: evalsymlink failure on '" + abs + "' : permission denied\n",
+	})
+	if a.File != "base/cm.yaml" {
+		t.Errorf("expected base/cm.yaml, got %q", a.File)
+	}
+	if a.Line != 0 {
+		t.Errorf("expected no line, got %d", a.Line)
+	}
+}
+
+func TestBuildFailureAnnotation_FallsBackToKustomizationFile(t *testing.T) {
+	chdirTemp(t)
+	mustWriteFile(t, filepath.Join("overlay", "kustomization.yml"), "resources:\n- missing.yaml\n")
+
+	a := buildFailureAnnotation(RootResult{
+		Root:   "overlay",
+		Status: statusTimedOut,
+		Stderr: "Error: accumulating resources from 'missing.yaml': no such file or directory\n",
+	})
+	if a.File != "overlay/kustomization.yml" {
+		t.Errorf("expected fallback to overlay/kustomization.yml, got %q", a.File)
+	}
+	if !strings.Contains(a.Title, "timed out") {
+		t.Errorf("expected title to mention timed out, got %q", a.Title)
+	}
+}
+
+func TestAnnotationString_Escapes(t *testing.T) {
+	a := annotation{File: "a,b:c.yaml", Line: 4, Title: "t", Message: "100% broken\nnext"}
+	got := a.String()
+	want := "::error file=a%2Cb%3Ac.yaml,line=4,title=t::100%25 broken%0Anext"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEmitBuildAnnotations_RespectsCap(t *testing.T) {
+	chdirTemp(t)
+	results := []RootResult{
+		{Root: "a", Status: statusFailed, Stderr: "Error: one"},
+		{Root: "ok", Status: statusSuccess},
+		{Root: "b", Status: statusFailed, Stderr: "Error: two"},
+		{Root: "c", Status: statusCanceled},
+		{Root: "d", Status: statusTimedOut},
+	}
+
+	var buf bytes.Buffer
+	emitBuildAnnotations(&buf, results, 2)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 annotations, got %d:\n%s", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[0], "file=a/kustomization.yaml") || !strings.HasSuffix(lines[1], "::Error: two") {
+		t.Errorf("unexpected annotations:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	emitBuildAnnotations(&buf, results, 0)
+	if buf.Len() != 0 {
+		t.Errorf("expected no annotations with max 0, got:\n%s", buf.String())
+	}
+}
@@ -10,8 +10,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
+	"time"
 )
 
 type runCommandFunc func(ctx context.Context, name string, args []string, stdout, stderr io.Writer) error
@@ -30,6 +32,82 @@ type Summary struct {
 	Roots         int      `json:"roots"`
 	FailedRoots   []string `json:"failed_roots"`
 	CanceledRoots []string `json:"canceled_roots"`
+	TimedOut      int      `json:"timed_out"`
+	TimedOutRoots []string `json:"timed_out_roots"`
+	Orphans       []string `json:"orphans"`
+	// SchemaErrors counts schema validation findings across all roots.
+	SchemaErrors int `json:"schema_errors"`
+	// DeprecatedAPIs and RemovedAPIs count objects using APIs deprecated in,
+	// or removed by, the target Kubernetes version.
+	DeprecatedAPIs int `json:"deprecated_apis"`
+	RemovedAPIs    int `json:"removed_apis"`
+	// PolicyViolations counts findings of the selected policy rules.
+	PolicyViolations int `json:"policy_violations"`
+	// Results holds one entry per root, in the order roots were given.
+	Results []RootResult `json:"results"`
+}
+
+// Root build statuses reported in RootResult.Status.
+const (
+	statusSuccess  = "success"
+	statusFailed   = "failed"
+	statusCanceled = "canceled"
+	statusTimedOut = "timed_out"
+)
+
+// RootResult records the outcome of building a single root.
+type RootResult struct {
+	Root            string  `json:"root"`
+	Status          string  `json:"status"`
+	Attempts        int     `json:"attempts"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	// ExitCode is the kustomize exit code, or -1 when it did not exit on its own.
+	ExitCode int `json:"exit_code"`
+	// OutputPath is the rendered manifest file, or the error file on failure.
+	OutputPath string `json:"output_path,omitempty"`
+	Bytes      int    `json:"bytes"`
+	Documents  int    `json:"documents"`
+	// Kinds counts rendered documents by apiVersion/kind.
+	Kinds map[string]int `json:"kinds,omitempty"`
+	// Findings lists problems detected by the checks run on this root.
+	Findings []Finding `json:"findings,omitempty"`
+	// Stderr is the kustomize stderr of the last attempt, kept for reports.
+	Stderr string `json:"-"`
+}
+
+// buildOptions holds the per-root build settings derived from Config.
+type buildOptions struct {
+	OutputDir      string
+	LoadRestrictor string
+	EnableHelm     bool
+	KustomizePath  string
+	Retries        int
+	RetryBackoff   time.Duration
+	// Layout is the output layout, layoutFlat, layoutSplit or layoutTree.
+	Layout string
+	// Format is the output format, e.g. formatYAML or formatJSON.
+	Format string
+	// Checkers inspect the rendered manifests of every successful build.
+	Checkers []manifestChecker
+}
+
+// manifestChecker inspects the rendered manifests of a root and reports findings.
+// Implementations are shared by concurrent builds.
+type manifestChecker interface {
+	checkManifests(root string, docs []manifest) []Finding
+}
+
+func newBuildOptions(conf Config, kustomizePath string) buildOptions {
+	return buildOptions{
+		OutputDir:      conf.OutputDir,
+		LoadRestrictor: conf.LoadRestrictor,
+		EnableHelm:     conf.EnableHelm,
+		KustomizePath:  kustomizePath,
+		Retries:        conf.BuildRetries,
+		RetryBackoff:   conf.RetryBackoff,
+		Layout:         conf.OutputLayout,
+		Format:         conf.OutputFormat,
+	}
 }
 
 func BuildKustomizations(roots []string, conf Config, kustomizePath string) Summary {
@@ -42,44 +120,71 @@ func buildKustomizations(roots []string, conf Config, kustomizePath string, runn
 	}
 
 	ctx := context.Background()
+	if conf.RunTimeout > 0 {
+		var cancelRun context.CancelFunc
+		ctx, cancelRun = context.WithTimeout(ctx, conf.RunTimeout)
+		defer cancelRun()
+	}
 	var cancel context.CancelFunc
 	if conf.FailFast {
 		ctx, cancel = context.WithCancel(ctx)
 		defer cancel()
 	}
 
+	parallelism := conf.Parallelism
+	if parallelism <= 0 {
+		parallelism = runtime.NumCPU()
+	}
+
 	var wg sync.WaitGroup
-	// Limit concurrency to 4
-	sem := make(chan struct{}, 4)
+	sem := make(chan struct{}, parallelism)
+
+	opts := newBuildOptions(conf, kustomizePath)
+	opts.Checkers = newManifestCheckers(conf)
 
 	var mu sync.Mutex
 	summary := Summary{
-		Roots: len(roots),
+		Roots:   len(roots),
+		Results: make([]RootResult, len(roots)),
+	}
+	markCanceled := func(i int, d string) {
+		mu.Lock()
+		summary.Canceled++
+		summary.CanceledRoots = append(summary.CanceledRoots, d)
+		summary.Results[i] = RootResult{Root: d, Status: statusCanceled, ExitCode: -1}
+		mu.Unlock()
 	}
 
-	for _, dir := range roots {
-		if conf.FailFast && ctx.Err() != nil {
-			break
+	for i, dir := range roots {
+		// Roots not launched after fail-fast or the run deadline count as canceled.
+		if ctx.Err() != nil {
+			markCanceled(i, dir)
+			continue
 		}
 		wg.Add(1)
-		go func(d string) {
+		go func(i int, d string) {
 			defer wg.Done()
 			sem <- struct{}{}
 			defer func() { <-sem }()
 
-			if conf.FailFast && ctx.Err() != nil {
-				mu.Lock()
-				summary.Canceled++
-				summary.CanceledRoots = append(summary.CanceledRoots, d)
-				mu.Unlock()
+			if ctx.Err() != nil {
+				markCanceled(i, d)
 				return
 			}
 
-			logMsg, err := buildKustomization(ctx, d, conf.OutputDir, conf.LoadRestrictor, conf.EnableHelm, kustomizePath, runner)
+			rootCtx := ctx
+			if conf.BuildTimeout > 0 {
+				var cancelRoot context.CancelFunc
+				rootCtx, cancelRoot = context.WithTimeout(ctx, conf.BuildTimeout)
+				defer cancelRoot()
+			}
+
+			result, logMsg, err := buildRoot(rootCtx, d, opts, runner)
 
 			// Critical section for updating summary and printing logs
 			mu.Lock()
 			defer mu.Unlock()
+			defer func() { summary.Results[i] = result }()
 
 			fmt.Println("::group::Building " + d)
 			if logMsg != "" {
@@ -88,28 +193,41 @@ func buildKustomizations(roots []string, conf Config, kustomizePath string, runn
 			fmt.Println("::endgroup::")
 
 			if err != nil {
-				if errors.Is(err, context.Canceled) {
+				// Only the per-root deadline counts as a timeout; fail-fast and
+				// the run deadline cancel the shared context.
+				if ctx.Err() != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
 					summary.Canceled++
 					summary.CanceledRoots = append(summary.CanceledRoots, d)
+					result.Status = statusCanceled
 					return
 				}
-				summary.Failed++
-				summary.FailedRoots = append(summary.FailedRoots, d)
+				if errors.Is(err, context.DeadlineExceeded) {
+					summary.TimedOut++
+					summary.TimedOutRoots = append(summary.TimedOutRoots, d)
+					result.Status = statusTimedOut
+				} else {
+					summary.Failed++
+					summary.FailedRoots = append(summary.FailedRoots, d)
+					result.Status = statusFailed
+				}
 				if conf.FailFast && cancel != nil {
 					cancel()
 				}
 			} else {
 				summary.Success++
+				result.Status = statusSuccess
+				summary.SchemaErrors += countFindings(result.Findings, "schema-invalid")
+				summary.DeprecatedAPIs += countFindings(result.Findings, "api-deprecated")
+				summary.RemovedAPIs += countFindings(result.Findings, "api-removed")
+				summary.PolicyViolations += countPolicyViolations(result.Findings)
 			}
-		}(dir)
-	}
-
-	// If fail-fast triggered, count any unlaunched roots as canceled.
-	if conf.FailFast && ctx.Err() != nil {
-		// Best-effort: identify remaining roots not yet launched based on Failed/Success/Canceled counts.
+		}(i, dir)
 	}
 
 	wg.Wait()
+	if conf.RunTimeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		fmt.Printf("⏱️ Run deadline of %s exceeded; %d roots canceled.\n", conf.RunTimeout, summary.Canceled)
+	}
 	return summary
 }
 
@@ -118,58 +236,111 @@ func BuildKustomization(ctx context.Context, dir, outputDir, loadRestrictor stri
 }
 
 func buildKustomization(ctx context.Context, dir, outputDir, loadRestrictor string, enableHelm bool, kustomizePath string, runner runCommandFunc) (string, error) {
+	opts := buildOptions{
+		OutputDir:      outputDir,
+		LoadRestrictor: loadRestrictor,
+		EnableHelm:     enableHelm,
+		KustomizePath:  kustomizePath,
+	}
+	_, logMsg, err := buildRoot(ctx, dir, opts, runner)
+	return logMsg, err
+}
+
+// buildRoot runs kustomize build for dir, retrying transient failures, and
+// writes the rendered output (or the error file) into opts.OutputDir.
+func buildRoot(ctx context.Context, dir string, opts buildOptions, runner runCommandFunc) (result RootResult, logMsg string, buildErr error) {
 	if runner == nil {
 		runner = defaultRunCommand
 	}
+	result = RootResult{Root: dir}
+	start := time.Now()
+	defer func() { result.DurationSeconds = time.Since(start).Seconds() }()
 
 	buildDir := dir
 	if buildDir == "" {
 		buildDir = "."
 	}
 
-	fileName := "kustomization.yaml"
-	path := filepath.Join(buildDir, fileName)
-	if !fileExists(path) {
-		fileName = "kustomization.yml"
-		path = filepath.Join(buildDir, fileName)
-		if !fileExists(path) {
-			// Skip if neither variant exists
-			return "", nil
-		}
+	outName := flatOutputName(dir)
+	if outName == "" {
+		// Skip if no kustomization file variant exists
+		return result, "", nil
 	}
-
-	outName := sanitizeOutName(dir) + "_" + fileName
-	outPath := filepath.Join(outputDir, outName)
+	outPath := filepath.Join(opts.OutputDir, withFormatExt(outName, opts.Format))
 
 	var args []string
-	args = append(args, "build", buildDir, "--load-restrictor="+loadRestrictor)
-	if enableHelm {
+	args = append(args, "build", buildDir, "--load-restrictor="+opts.LoadRestrictor)
+	if opts.EnableHelm {
 		args = append(args, "--enable-helm")
 	}
 
-	stdout := &bytes.Buffer{}
-	stderr := &bytes.Buffer{}
-	if err := runner(ctx, kustomizePath, args, stdout, stderr); err != nil {
+	var stdout, stderr *bytes.Buffer
+	var err error
+	var retryLog []string
+	for {
+		result.Attempts++
+		stdout, stderr = &bytes.Buffer{}, &bytes.Buffer{}
+		err = runner(ctx, opts.KustomizePath, args, stdout, stderr)
+		if err == nil || ctx.Err() != nil || result.Attempts > opts.Retries || !isRetryableBuildError(stderr.String()) {
+			break
+		}
+		wait := retryDelay(opts.RetryBackoff, result.Attempts)
+		retryLog = append(retryLog, fmt.Sprintf("🔁 Attempt %d for %s failed with a transient error, retrying in %s:\n%s", result.Attempts, dir, wait, tail(stderr.String(), 5)))
+		if !sleepContext(ctx, wait) {
+			break
+		}
+	}
+	prefix := ""
+	if len(retryLog) > 0 {
+		prefix = strings.Join(retryLog, "\n") + "\n"
+	}
+
+	if err != nil {
+		result.ExitCode = exitCode(err)
+		result.Stderr = stderr.String()
 		if errors.Is(ctx.Err(), context.Canceled) {
-			return fmt.Sprintf("⏭️ Canceled: %s", dir), context.Canceled
+			return result, prefix + fmt.Sprintf("⏭️ Canceled: %s", dir), context.Canceled
 		}
-		// write error file with -err.yaml/-err.yml suffix
-		errOut := strings.TrimSuffix(outName, ".yaml")
-		errOut = strings.TrimSuffix(errOut, ".yml")
-		if strings.HasSuffix(outName, ".yaml") {
-			errOut += "-err.yaml"
-		} else {
-			errOut += "-err.yml"
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return result, prefix + fmt.Sprintf("⏱️ Timed out: %s", dir), context.DeadlineExceeded
 		}
-		_ = os.WriteFile(filepath.Join(outputDir, errOut), stderr.Bytes(), 0o644)
+		errPath := errorOutputPath(opts, dir, outName)
+		if os.MkdirAll(filepath.Dir(errPath), 0o755) == nil && os.WriteFile(errPath, stderr.Bytes(), 0o644) == nil {
+			result.OutputPath = errPath
+		}
+
+		return result, prefix + fmt.Sprintf("❌ Failed: %s\n%s\nError: %v", dir, tail(stderr.String(), 20), err), fmt.Errorf("build failed")
+	}
 
-		return fmt.Sprintf("❌ Failed: %s\n%s\nError: %v", dir, tail(stderr.String(), 20), err), fmt.Errorf("build failed")
+	docs, parseErr := parseManifests(stdout.Bytes())
+	written, err := writeRenderedOutput(opts, dir, outPath, stdout.Bytes(), docs, parseErr)
+	if err != nil {
+		return result, prefix + fmt.Sprintf("❌ Failed to write output for %s: %v", dir, err), fmt.Errorf("write failed: %v", err)
+	}
+	result.OutputPath = written
+	result.Bytes = stdout.Len()
+	if parseErr != nil {
+		prefix += fmt.Sprintf("⚠️ Could not parse rendered output of %s: %v\n", dir, parseErr)
+	}
+	result.Documents = len(docs)
+	result.Kinds = countKinds(docs)
+	for _, c := range opts.Checkers {
+		result.Findings = append(result.Findings, c.checkManifests(dir, docs)...)
 	}
+	msg := prefix + fmt.Sprintf("✅ Built %s (%d documents)", dir, result.Documents)
+	for _, f := range result.Findings {
+		msg += fmt.Sprintf("\n⚠️ [%s] %s", f.Rule, f.Message)
+	}
+	return result, msg, nil
+}
 
-	if err := os.WriteFile(outPath, stdout.Bytes(), 0o644); err != nil {
-		return fmt.Sprintf("❌ Failed to write output for %s: %v", dir, err), fmt.Errorf("write failed: %v", err)
+// exitCode extracts the process exit code from a runner error, or -1 if there is none.
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
 	}
-	return fmt.Sprintf("✅ Built %s", dir), nil
+	return -1
 }
 
 func sanitizeOutName(dir string) string {
@@ -6,10 +6,13 @@ import (
 	"errors"
 	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 func writeKustomizationYAML(t *testing.T, dir string) {
@@ -133,6 +136,35 @@ func TestBuildKustomization_ExplicitDirBuilds(t *testing.T) {
 	}
 }
 
+func TestBuildKustomization_CapitalizedKustomizationFile(t *testing.T) {
+	runner := func(ctx context.Context, name string, args []string, stdout, stderr io.Writer) error {
+		_, _ = io.WriteString(stdout, "apiVersion: v1\nkind: List\nitems: []\n")
+		return nil
+	}
+
+	tmpDir := t.TempDir()
+	appDir := filepath.Join(tmpDir, "legacy")
+	if err := os.MkdirAll(appDir, 0o755); err != nil {
+		t.Fatalf("Failed to create app dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(appDir, "Kustomization"), []byte("resources: []\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write Kustomization: %v", err)
+	}
+
+	outDir := filepath.Join(tmpDir, "out")
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		t.Fatalf("Failed to create output dir: %v", err)
+	}
+
+	logMsg, err := buildKustomization(context.Background(), appDir, outDir, "LoadRestrictionsNone", false, "kustomize", runner)
+	if err != nil {
+		t.Fatalf("Expected build to succeed, got error: %v (log=%s)", err, logMsg)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, sanitizeOutName(appDir)+"_kustomization.yaml")); err != nil {
+		t.Fatalf("Expected output file to exist, got error: %v", err)
+	}
+}
+
 func TestBuildKustomization_FailureWritesErrorFile(t *testing.T) {
 	stderrOut := "some error\nsecond line\n"
 	runner := func(ctx context.Context, name string, args []string, stdout, stderr io.Writer) error {
@@ -228,11 +260,13 @@ func TestBuildKustomizations_FailFastCancelsOthers_NoOutputsForCanceled(t *testi
 	roots := []string{app1, app2, app3}
 	failDir := app2
 
+	// The runner below waits until every root has started, so all must run concurrently.
 	conf := Config{
 		OutputDir:      outDir,
 		LoadRestrictor: "LoadRestrictionsNone",
 		EnableHelm:     false,
 		FailFast:       true,
+		Parallelism:    len(roots),
 	}
 
 	var mu sync.Mutex
@@ -298,6 +332,210 @@ func TestBuildKustomizations_FailFastCancelsOthers_NoOutputsForCanceled(t *testi
 	}
 }
 
+func TestBuildKustomizations_BuildTimeoutMarksRootTimedOut(t *testing.T) {
+	tmpDir := t.TempDir()
+	outDir := filepath.Join(tmpDir, "out")
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		t.Fatalf("Failed to create output dir: %v", err)
+	}
+
+	slow := filepath.Join(tmpDir, "slow")
+	fast := filepath.Join(tmpDir, "fast")
+	for _, d := range []string{slow, fast} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatalf("Failed to create app dir %s: %v", d, err)
+		}
+		writeKustomizationYAML(t, d)
+	}
+
+	runner := func(ctx context.Context, name string, args []string, stdout, stderr io.Writer) error {
+		if args[1] == slow {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		_, _ = io.WriteString(stdout, "apiVersion: v1\nkind: List\nitems: []\n")
+		return nil
+	}
+
+	conf := Config{
+		OutputDir:      outDir,
+		LoadRestrictor: "LoadRestrictionsNone",
+		Parallelism:    2,
+		BuildTimeout:   50 * time.Millisecond,
+	}
+
+	summary := buildKustomizations([]string{slow, fast}, conf, "kustomize", runner)
+	if summary.TimedOut != 1 || len(summary.TimedOutRoots) != 1 || summary.TimedOutRoots[0] != slow {
+		t.Fatalf("Expected %s to time out, got %+v", slow, summary)
+	}
+	if summary.Success != 1 || summary.Failed != 0 || summary.Canceled != 0 {
+		t.Fatalf("Expected timeout to be distinct from failed and canceled, got %+v", summary)
+	}
+}
+
+func TestBuildKustomizations_RunTimeoutCancelsRemainingRoots(t *testing.T) {
+	tmpDir := t.TempDir()
+	outDir := filepath.Join(tmpDir, "out")
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		t.Fatalf("Failed to create output dir: %v", err)
+	}
+
+	var roots []string
+	for _, name := range []string{"a", "b", "c"} {
+		d := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatalf("Failed to create app dir %s: %v", d, err)
+		}
+		writeKustomizationYAML(t, d)
+		roots = append(roots, d)
+	}
+
+	runner := func(ctx context.Context, name string, args []string, stdout, stderr io.Writer) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	conf := Config{
+		OutputDir:      outDir,
+		LoadRestrictor: "LoadRestrictionsNone",
+		Parallelism:    1,
+		RunTimeout:     50 * time.Millisecond,
+	}
+
+	summary := buildKustomizations(roots, conf, "kustomize", runner)
+	if summary.Canceled != len(roots) {
+		t.Fatalf("Expected all %d roots canceled by the run deadline, got %+v", len(roots), summary)
+	}
+	if summary.TimedOut != 0 || summary.Failed != 0 {
+		t.Fatalf("Expected no timed out or failed roots, got %+v", summary)
+	}
+}
+
+func TestBuildKustomizations_RetriesTransientFailures(t *testing.T) {
+	tmpDir := t.TempDir()
+	outDir := filepath.Join(tmpDir, "out")
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		t.Fatalf("Failed to create output dir: %v", err)
+	}
+
+	flaky := filepath.Join(tmpDir, "flaky")
+	broken := filepath.Join(tmpDir, "broken")
+	for _, d := range []string{flaky, broken} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatalf("Failed to create app dir %s: %v", d, err)
+		}
+		writeKustomizationYAML(t, d)
+	}
+
+	var mu sync.Mutex
+	calls := map[string]int{}
+	runner := func(ctx context.Context, name string, args []string, stdout, stderr io.Writer) error {
+		mu.Lock()
+		calls[args[1]]++
+		n := calls[args[1]]
+		mu.Unlock()
+
+		if args[1] == broken {
+			_, _ = io.WriteString(stderr, "Error: yaml: line 3: mapping values are not allowed in this context\n")
+			return errors.New("exit status 1")
+		}
+		if n < 3 {
+			_, _ = io.WriteString(stderr, "Error: Get \"https://charts.example.com/index.yaml\": dial tcp: i/o timeout\n")
+			return errors.New("exit status 1")
+		}
+		_, _ = io.WriteString(stdout, "apiVersion: v1\nkind: List\nitems: []\n")
+		return nil
+	}
+
+	conf := Config{
+		OutputDir:      outDir,
+		LoadRestrictor: "LoadRestrictionsNone",
+		Parallelism:    2,
+		BuildRetries:   3,
+		RetryBackoff:   time.Millisecond,
+	}
+
+	summary := buildKustomizations([]string{flaky, broken}, conf, "kustomize", runner)
+	if summary.Success != 1 || summary.Failed != 1 {
+		t.Fatalf("Expected 1 success and 1 failure, got %+v", summary)
+	}
+
+	expected := []RootResult{
+		{Root: flaky, Status: statusSuccess, Attempts: 3},
+		{Root: broken, Status: statusFailed, Attempts: 1},
+	}
+	if len(summary.Results) != len(expected) {
+		t.Fatalf("Expected %d results, got %+v", len(expected), summary.Results)
+	}
+	for i, want := range expected {
+		got := summary.Results[i]
+		if got.Root != want.Root || got.Status != want.Status || got.Attempts != want.Attempts {
+			t.Errorf("result %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+}
+
+func TestBuildKustomizations_RecordsPerRootResults(t *testing.T) {
+	tmpDir := t.TempDir()
+	outDir := filepath.Join(tmpDir, "out")
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		t.Fatalf("Failed to create output dir: %v", err)
+	}
+
+	ok := filepath.Join(tmpDir, "ok")
+	bad := filepath.Join(tmpDir, "bad")
+	for _, d := range []string{ok, bad} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatalf("Failed to create app dir %s: %v", d, err)
+		}
+		writeKustomizationYAML(t, d)
+	}
+
+	rendered := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: b\n---\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n"
+	runner := func(ctx context.Context, name string, args []string, stdout, stderr io.Writer) error {
+		if args[1] == bad {
+			// A real process exiting with status 3
+			return exec.Command("sh", "-c", "exit 3").Run()
+		}
+		_, _ = io.WriteString(stdout, rendered)
+		return nil
+	}
+
+	conf := Config{
+		OutputDir:      outDir,
+		LoadRestrictor: "LoadRestrictionsNone",
+		Parallelism:    1,
+	}
+
+	summary := buildKustomizations([]string{ok, bad}, conf, "kustomize", runner)
+
+	okRes := summary.Results[0]
+	if okRes.Status != statusSuccess || okRes.ExitCode != 0 {
+		t.Fatalf("unexpected result for ok root: %+v", okRes)
+	}
+	if okRes.Documents != 3 || okRes.Bytes != len(rendered) {
+		t.Fatalf("expected 3 documents and %d bytes, got %+v", len(rendered), okRes)
+	}
+	wantKinds := map[string]int{"v1/ConfigMap": 2, "apps/v1/Deployment": 1}
+	if !reflect.DeepEqual(okRes.Kinds, wantKinds) {
+		t.Fatalf("Expected kinds %v, got %v", wantKinds, okRes.Kinds)
+	}
+	if okRes.OutputPath != filepath.Join(outDir, sanitizeOutName(ok)+"_kustomization.yaml") {
+		t.Fatalf("unexpected output path %q", okRes.OutputPath)
+	}
+	if okRes.DurationSeconds <= 0 {
+		t.Fatalf("expected a positive duration, got %v", okRes.DurationSeconds)
+	}
+
+	badRes := summary.Results[1]
+	if badRes.Status != statusFailed || badRes.ExitCode != 3 {
+		t.Fatalf("unexpected result for bad root: %+v", badRes)
+	}
+	if badRes.OutputPath != filepath.Join(outDir, sanitizeOutName(bad)+"_kustomization-err.yaml") {
+		t.Fatalf("expected error file as output path, got %q", badRes.OutputPath)
+	}
+}
+
 func TestDefaultRunCommand(t *testing.T) {
 	ctx := context.Background()
 	var stdout, stderr bytes.Buffer
@@ -312,3 +550,50 @@ func TestDefaultRunCommand(t *testing.T) {
 		t.Errorf("expected 'hello', got '%s'", stdout.String())
 	}
 }
+
+func TestBuildKustomizations_ValidatesSchemas(t *testing.T) {
+	tmpDir := t.TempDir()
+	outDir := filepath.Join(tmpDir, "out")
+	app := filepath.Join(tmpDir, "app")
+	for _, d := range []string{outDir, app} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeKustomizationYAML(t, app)
+	schemas := filepath.Join(tmpDir, "schemas")
+	mustWriteFile(t, filepath.Join(schemas, "configmap-v1.json"), `{"type":"object","properties":{"data":{"type":"object","additionalProperties":{"type":"string"}}}}`)
+
+	runner := func(ctx context.Context, name string, args []string, stdout, stderr io.Writer) error {
+		_, _ = io.WriteString(stdout, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\ndata:\n  n: 1\n  m: 2\n")
+		return nil
+	}
+	conf := Config{
+		OutputDir:       outDir,
+		LoadRestrictor:  "LoadRestrictionsNone",
+		Parallelism:     1,
+		ValidateSchemas: true,
+		SchemaDir:       schemas,
+		WorkingDir:      tmpDir,
+	}
+
+	summary := buildKustomizations([]string{app}, conf, "kustomize", runner)
+
+	if summary.SchemaErrors != 2 {
+		t.Fatalf("expected 2 schema errors, got %d (%+v)", summary.SchemaErrors, summary.Results[0].Findings)
+	}
+	if f := summary.Results[0].Findings[0]; f.Resource != "ConfigMap/a" || !strings.Contains(f.Message, "data.m: expected string, got integer") {
+		t.Errorf("unexpected finding %+v", f)
+	}
+	if summary.Success != 1 {
+		t.Errorf("schema errors must not fail the build itself, got %+v", summary)
+	}
+}
+
+// fakeRenderRunner returns a runner that "renders" output for every root.
+func fakeRenderRunner(output string) runCommandFunc {
+	return func(ctx context.Context, name string, args []string, stdout, stderr io.Writer) error {
+		_, err := io.WriteString(stdout, output)
+		return err
+	}
+}
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"log"
+	"math"
 	"os"
+	"runtime"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
@@ -13,9 +18,32 @@ type Config struct {
 	LoadRestrictor   string
 	WorkingDir       string
 	BuildAll         bool
+	RootDetection    string
+	IncludePaths     []string
+	ExcludePaths     []string
 	ChangedOnly      bool
+	BaseRef          string
+	RebuildAllOn     []string
+	ChangedIgnore    []string
 	FailOnError      bool
 	FailFast         bool
+	FailOnOrphans    bool
+	Parallelism      int
+	BuildTimeout     time.Duration
+	RunTimeout       time.Duration
+	BuildRetries     int
+	RetryBackoff     time.Duration
+	MaxAnnotations   int
+	JUnitReport      string
+	SARIFReport      string
+	ValidateSchemas  bool
+	SchemaDir        string
+	KubeVersion      string
+	FailOnRemovedAPI bool
+	PolicyRules      []string
+	Diff             bool
+	OutputLayout     string
+	OutputFormat     string
 }
 
 func LoadConfig() Config {
@@ -27,9 +55,32 @@ func LoadConfig() Config {
 		LoadRestrictor:   getInput("load-restrictor", "LoadRestrictionsNone"),
 		WorkingDir:       getInput("working-directory", "."),
 		BuildAll:         strings.ToLower(getInput("build-all", "false")) == "true",
+		RootDetection:    strings.ToLower(getInput("root-detection", "ancestry")),
+		IncludePaths:     getListInput("include-paths"),
+		ExcludePaths:     getListInput("exclude-paths"),
 		ChangedOnly:      strings.ToLower(getInput("changed-only", "true")) == "true",
+		BaseRef:          getInput("base-ref", ""),
+		RebuildAllOn:     getListInput("rebuild-all-on"),
+		ChangedIgnore:    getListInput("changed-ignore"),
 		FailOnError:      strings.ToLower(getInput("fail-on-error", "false")) == "true",
 		FailFast:         strings.ToLower(getInput("fail-fast", "false")) == "true",
+		FailOnOrphans:    strings.ToLower(getInput("fail-on-orphans", "false")) == "true",
+		Parallelism:      getIntInput("parallelism", runtime.NumCPU(), 1, math.MaxInt),
+		BuildTimeout:     getDurationInput("build-timeout", 0),
+		RunTimeout:       getDurationInput("run-timeout", 0),
+		BuildRetries:     getIntInput("build-retries", 0, 0, maxBuildRetries),
+		RetryBackoff:     getDurationInput("retry-backoff", 5*time.Second),
+		MaxAnnotations:   getIntInput("max-annotations", 10, 0, math.MaxInt),
+		JUnitReport:      getInput("junit-report", ""),
+		SARIFReport:      getInput("sarif-report", ""),
+		ValidateSchemas:  strings.ToLower(getInput("validate-schemas", "false")) == "true",
+		SchemaDir:        getInput("schema-dir", ""),
+		KubeVersion:      getInput("kubernetes-version", ""),
+		FailOnRemovedAPI: strings.ToLower(getInput("fail-on-removed-apis", "false")) == "true",
+		PolicyRules:      getListInput("policy-rules"),
+		Diff:             strings.ToLower(getInput("diff", "false")) == "true",
+		OutputLayout:     strings.ToLower(getInput("output-layout", layoutFlat)),
+		OutputFormat:     strings.ToLower(getInput("output-format", formatYAML)),
 	}
 }
 
@@ -57,3 +108,53 @@ func getInput(name, defaultVal string) string {
 
 	return defaultVal
 }
+
+// getListInput splits a multi-line or comma-separated input into trimmed, non-empty items.
+func getListInput(name string) []string {
+	raw := getInput(name, "")
+	var out []string
+	for _, line := range strings.Split(raw, "\n") {
+		for _, item := range strings.Split(line, ",") {
+			item = strings.TrimSpace(item)
+			if item != "" {
+				out = append(out, item)
+			}
+		}
+	}
+	return out
+}
+
+// getIntInput parses an integer input of at least min, falling back to defaultVal when unset or invalid.
+func getIntInput(name string, defaultVal, min, max int) int {
+	raw := strings.TrimSpace(getInput(name, ""))
+	if raw == "" {
+		return defaultVal
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < min {
+		log.Printf("⚠️ Invalid %s %q, using %d", name, raw, defaultVal)
+		return defaultVal
+	}
+	if n > max {
+		log.Printf("⚠️ %s %d exceeds the maximum, using %d", name, n, max)
+		return max
+	}
+	return n
+}
+
+// getDurationInput parses a Go duration input (e.g. "90s", "10m"); "0" disables the limit.
+func getDurationInput(name string, defaultVal time.Duration) time.Duration {
+	raw := strings.TrimSpace(getInput(name, ""))
+	if raw == "" {
+		return defaultVal
+	}
+	if raw == "0" {
+		return 0
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		log.Printf("⚠️ Invalid %s %q, using %s", name, raw, defaultVal)
+		return defaultVal
+	}
+	return d
+}
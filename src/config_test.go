@@ -3,6 +3,7 @@ package main
 import (
 	"os"
 	"testing"
+	"time"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -63,3 +64,51 @@ func TestLoadConfig(t *testing.T) {
 		t.Errorf("Expected OutputDir 'legacy-out', got '%s'", config.OutputDir)
 	}
 }
+
+func TestGetListInput(t *testing.T) {
+	t.Setenv("INPUT_REBUILD-ALL-ON", "components/**\n .github/workflows/*.yml, helm/**\n\n")
+
+	got := getListInput("rebuild-all-on")
+	expected := []string{"components/**", ".github/workflows/*.yml", "helm/**"}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("index %d: expected %q, got %q", i, expected[i], got[i])
+		}
+	}
+}
+
+func TestGetIntAndDurationInputs(t *testing.T) {
+	t.Setenv("INPUT_PARALLELISM", "8")
+	t.Setenv("INPUT_BUILD-TIMEOUT", "90s")
+	t.Setenv("INPUT_RUN-TIMEOUT", "0")
+
+	config := LoadConfig()
+	if config.Parallelism != 8 {
+		t.Errorf("Expected Parallelism 8, got %d", config.Parallelism)
+	}
+	if config.BuildTimeout != 90*time.Second {
+		t.Errorf("Expected BuildTimeout 90s, got %s", config.BuildTimeout)
+	}
+	if config.RunTimeout != 0 {
+		t.Errorf("Expected RunTimeout 0, got %s", config.RunTimeout)
+	}
+
+	// Invalid values fall back to defaults
+	t.Setenv("INPUT_PARALLELISM", "-2")
+	t.Setenv("INPUT_BUILD-TIMEOUT", "soon")
+	if got := getIntInput("parallelism", 3, 1, 16); got != 3 {
+		t.Errorf("Expected fallback parallelism 3, got %d", got)
+	}
+	if got := getDurationInput("build-timeout", time.Minute); got != time.Minute {
+		t.Errorf("Expected fallback timeout 1m, got %s", got)
+	}
+
+	// Values above the maximum are capped
+	t.Setenv("INPUT_BUILD-RETRIES", "40")
+	if got := LoadConfig().BuildRetries; got != maxBuildRetries {
+		t.Errorf("Expected build-retries capped at %d, got %d", maxBuildRetries, got)
+	}
+}
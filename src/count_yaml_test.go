@@ -24,9 +24,9 @@ func TestCountYAMLFiles_CountsYamlAndYmlAndIgnoresNonYaml(t *testing.T) {
 		}
 	}
 
-	got, err := countYAMLFiles(dir)
+	got, err := countManifestFiles(dir, formatYAML)
 	if err != nil {
-		t.Fatalf("countYAMLFiles error: %v", err)
+		t.Fatalf("countManifestFiles error: %v", err)
 	}
 	if got != 2 {
 		t.Fatalf("expected 2 YAML files, got %d", got)
@@ -54,9 +54,9 @@ func TestCountYAMLFiles_ExcludesErrorFiles(t *testing.T) {
 		}
 	}
 
-	got, err := countYAMLFiles(dir)
+	got, err := countManifestFiles(dir, formatYAML)
 	if err != nil {
-		t.Fatalf("countYAMLFiles error: %v", err)
+		t.Fatalf("countManifestFiles error: %v", err)
 	}
 	if got != len(include) {
 		t.Fatalf("expected %d YAML files (excluding error outputs), got %d", len(include), got)
@@ -82,9 +82,9 @@ func TestCountYAMLFiles_CountsFilesEndingWithErrSuffix(t *testing.T) {
 		}
 	}
 
-	got, err := countYAMLFiles(dir)
+	got, err := countManifestFiles(dir, formatYAML)
 	if err != nil {
-		t.Fatalf("countYAMLFiles error: %v", err)
+		t.Fatalf("countManifestFiles error: %v", err)
 	}
 	if got != 4 {
 		t.Fatalf("expected 4 YAML files (excluding only _kustomization-err artifacts), got %d", got)
@@ -113,11 +113,35 @@ func TestCountYAMLFiles_WorksWithNestedDirectories(t *testing.T) {
 		}
 	}
 
-	got, err := countYAMLFiles(dir)
+	got, err := countManifestFiles(dir, formatYAML)
 	if err != nil {
-		t.Fatalf("countYAMLFiles error: %v", err)
+		t.Fatalf("countManifestFiles error: %v", err)
 	}
 	if got != 2 {
 		t.Fatalf("expected 2 YAML files in nested dirs, got %d", got)
 	}
 }
+
+func TestCountYAMLFiles_ExcludesReportFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	for _, name := range []string{"ok.yaml", "junit.yaml", filepath.Join("reports", "junit.yml")} {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", filepath.Dir(p), err)
+		}
+		if err := os.WriteFile(p, []byte("test"), 0o644); err != nil {
+			t.Fatalf("write %s: %v", p, err)
+		}
+	}
+
+	got, err := countManifestFiles(dir, formatYAML, "junit.yaml", "reports/junit.yml", "")
+	if err != nil {
+		t.Fatalf("countManifestFiles error: %v", err)
+	}
+	if got != 1 {
+		t.Fatalf("expected 1 YAML file (excluding reports), got %d", got)
+	}
+}
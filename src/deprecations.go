@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// kubeVersion is a Kubernetes minor release such as 1.29.
+type kubeVersion struct {
+	Major, Minor int
+}
+
+func (v kubeVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+func (v kubeVersion) atLeast(o kubeVersion) bool {
+	return v.Major > o.Major || (v.Major == o.Major && v.Minor >= o.Minor)
+}
+
+// parseKubeVersion accepts "1.29", "v1.29" or "1.29.3".
+func parseKubeVersion(s string) (kubeVersion, error) {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(s), "v"), ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return kubeVersion{}, fmt.Errorf("invalid Kubernetes version %q: expected MAJOR.MINOR", s)
+	}
+	major, err1 := strconv.Atoi(parts[0])
+	minor, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || major < 0 || minor < 0 {
+		return kubeVersion{}, fmt.Errorf("invalid Kubernetes version %q: expected MAJOR.MINOR", s)
+	}
+	return kubeVersion{Major: major, Minor: minor}, nil
+}
+
+func mustKubeVersion(s string) kubeVersion {
+	v, err := parseKubeVersion(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// apiDeprecation records when an apiVersion of some kinds was deprecated and removed.
+type apiDeprecation struct {
+	APIVersion   string
+	Kinds        []string
+	DeprecatedIn kubeVersion
+	RemovedIn    kubeVersion
+	// Replacement is the apiVersion to migrate to, empty if the API is gone for good.
+	Replacement string
+}
+
+// apiDeprecations follows the Kubernetes deprecated API migration guide.
+var apiDeprecations = []apiDeprecation{
+	{"extensions/v1beta1", []string{"Deployment", "DaemonSet", "ReplicaSet"}, mustKubeVersion("1.9"), mustKubeVersion("1.16"), "apps/v1"},
+	{"extensions/v1beta1", []string{"NetworkPolicy"}, mustKubeVersion("1.9"), mustKubeVersion("1.16"), "networking.k8s.io/v1"},
+	{"extensions/v1beta1", []string{"PodSecurityPolicy"}, mustKubeVersion("1.11"), mustKubeVersion("1.16"), "policy/v1beta1"},
+	{"apps/v1beta1", []string{"Deployment", "StatefulSet", "ReplicaSet"}, mustKubeVersion("1.9"), mustKubeVersion("1.16"), "apps/v1"},
+	{"apps/v1beta2", []string{"Deployment", "StatefulSet", "DaemonSet", "ReplicaSet"}, mustKubeVersion("1.9"), mustKubeVersion("1.16"), "apps/v1"},
+
+	{"admissionregistration.k8s.io/v1beta1", []string{"MutatingWebhookConfiguration", "ValidatingWebhookConfiguration"}, mustKubeVersion("1.16"), mustKubeVersion("1.22"), "admissionregistration.k8s.io/v1"},
+	{"apiextensions.k8s.io/v1beta1", []string{"CustomResourceDefinition"}, mustKubeVersion("1.16"), mustKubeVersion("1.22"), "apiextensions.k8s.io/v1"},
+	{"apiregistration.k8s.io/v1beta1", []string{"APIService"}, mustKubeVersion("1.19"), mustKubeVersion("1.22"), "apiregistration.k8s.io/v1"},
+	{"authentication.k8s.io/v1beta1", []string{"TokenReview"}, mustKubeVersion("1.19"), mustKubeVersion("1.22"), "authentication.k8s.io/v1"},
+	{"authorization.k8s.io/v1beta1", []string{"SubjectAccessReview", "LocalSubjectAccessReview", "SelfSubjectAccessReview"}, mustKubeVersion("1.19"), mustKubeVersion("1.22"), "authorization.k8s.io/v1"},
+	{"certificates.k8s.io/v1beta1", []string{"CertificateSigningRequest"}, mustKubeVersion("1.19"), mustKubeVersion("1.22"), "certificates.k8s.io/v1"},
+	{"coordination.k8s.io/v1beta1", []string{"Lease"}, mustKubeVersion("1.19"), mustKubeVersion("1.22"), "coordination.k8s.io/v1"},
+	{"extensions/v1beta1", []string{"Ingress"}, mustKubeVersion("1.14"), mustKubeVersion("1.22"), "networking.k8s.io/v1"},
+	{"networking.k8s.io/v1beta1", []string{"Ingress", "IngressClass"}, mustKubeVersion("1.19"), mustKubeVersion("1.22"), "networking.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", []string{"ClusterRole", "ClusterRoleBinding", "Role", "RoleBinding"}, mustKubeVersion("1.17"), mustKubeVersion("1.22"), "rbac.authorization.k8s.io/v1"},
+	{"scheduling.k8s.io/v1beta1", []string{"PriorityClass"}, mustKubeVersion("1.14"), mustKubeVersion("1.22"), "scheduling.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", []string{"CSIDriver", "CSINode", "StorageClass", "VolumeAttachment"}, mustKubeVersion("1.19"), mustKubeVersion("1.22"), "storage.k8s.io/v1"},
+
+	{"batch/v1beta1", []string{"CronJob"}, mustKubeVersion("1.21"), mustKubeVersion("1.25"), "batch/v1"},
+	{"discovery.k8s.io/v1beta1", []string{"EndpointSlice"}, mustKubeVersion("1.21"), mustKubeVersion("1.25"), "discovery.k8s.io/v1"},
+	{"events.k8s.io/v1beta1", []string{"Event"}, mustKubeVersion("1.21"), mustKubeVersion("1.25"), "events.k8s.io/v1"},
+	{"autoscaling/v2beta1", []string{"HorizontalPodAutoscaler"}, mustKubeVersion("1.23"), mustKubeVersion("1.25"), "autoscaling/v2"},
+	{"policy/v1beta1", []string{"PodDisruptionBudget"}, mustKubeVersion("1.21"), mustKubeVersion("1.25"), "policy/v1"},
+	{"policy/v1beta1", []string{"PodSecurityPolicy"}, mustKubeVersion("1.21"), mustKubeVersion("1.25"), ""},
+	{"node.k8s.io/v1beta1", []string{"RuntimeClass"}, mustKubeVersion("1.20"), mustKubeVersion("1.25"), "node.k8s.io/v1"},
+
+	{"flowcontrol.apiserver.k8s.io/v1beta1", []string{"FlowSchema", "PriorityLevelConfiguration"}, mustKubeVersion("1.23"), mustKubeVersion("1.26"), "flowcontrol.apiserver.k8s.io/v1"},
+	{"autoscaling/v2beta2", []string{"HorizontalPodAutoscaler"}, mustKubeVersion("1.23"), mustKubeVersion("1.26"), "autoscaling/v2"},
+	{"storage.k8s.io/v1beta1", []string{"CSIStorageCapacity"}, mustKubeVersion("1.24"), mustKubeVersion("1.27"), "storage.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta2", []string{"FlowSchema", "PriorityLevelConfiguration"}, mustKubeVersion("1.26"), mustKubeVersion("1.29"), "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta3", []string{"FlowSchema", "PriorityLevelConfiguration"}, mustKubeVersion("1.29"), mustKubeVersion("1.32"), "flowcontrol.apiserver.k8s.io/v1"},
+}
+
+// lookupAPIDeprecation returns the deprecation entry for apiVersion/kind, if any.
+func lookupAPIDeprecation(apiVersion, kind string) (apiDeprecation, bool) {
+	for _, d := range apiDeprecations {
+		if d.APIVersion != apiVersion {
+			continue
+		}
+		for _, k := range d.Kinds {
+			if k == kind {
+				return d, true
+			}
+		}
+	}
+	return apiDeprecation{}, false
+}
+
+// deprecatedAPIChecker reports rendered objects using APIs that are
+// deprecated in, or removed by, the target Kubernetes version.
+type deprecatedAPIChecker struct {
+	target kubeVersion
+}
+
+func (c *deprecatedAPIChecker) checkManifests(root string, docs []manifest) []Finding {
+	var out []Finding
+	for _, doc := range docs {
+		d, ok := lookupAPIDeprecation(doc.APIVersion, doc.Kind)
+		if !ok {
+			continue
+		}
+		replacement := "no replacement is available"
+		if d.Replacement != "" {
+			replacement = "use " + d.Replacement
+		}
+		switch {
+		case c.target.atLeast(d.RemovedIn):
+			out = append(out, Finding{
+				Rule:     "api-removed",
+				Level:    levelError,
+				Message:  fmt.Sprintf("%s: %s %s was removed in Kubernetes %s; %s", doc.ResourceID(), d.APIVersion, doc.Kind, d.RemovedIn, replacement),
+				Resource: doc.ResourceID(),
+			})
+		case c.target.atLeast(d.DeprecatedIn):
+			out = append(out, Finding{
+				Rule:     "api-deprecated",
+				Level:    levelWarning,
+				Message:  fmt.Sprintf("%s: %s %s is deprecated since Kubernetes %s and removed in %s; %s", doc.ResourceID(), d.APIVersion, doc.Kind, d.DeprecatedIn, d.RemovedIn, replacement),
+				Resource: doc.ResourceID(),
+			})
+		}
+	}
+	return out
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseKubeVersion(t *testing.T) {
+	for in, want := range map[string]kubeVersion{
+		"1.29":    {1, 29},
+		"v1.25":   {1, 25},
+		"1.32.4":  {1, 32},
+		" 1.9 ":   {1, 9},
+		"v1.30.0": {1, 30},
+	} {
+		got, err := parseKubeVersion(in)
+		if err != nil || got != want {
+			t.Errorf("parseKubeVersion(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "1", "latest", "1.x", "1.2.3.4"} {
+		if _, err := parseKubeVersion(in); err == nil {
+			t.Errorf("parseKubeVersion(%q): expected error", in)
+		}
+	}
+}
+
+func TestDeprecatedAPIChecker(t *testing.T) {
+	docs, err := parseManifests([]byte(`apiVersion: policy/v1beta1
+kind: PodSecurityPolicy
+metadata: {name: restricted}
+---
+apiVersion: autoscaling/v2beta2
+kind: HorizontalPodAutoscaler
+metadata: {name: web, namespace: prod}
+---
+apiVersion: flowcontrol.apiserver.k8s.io/v1beta3
+kind: FlowSchema
+metadata: {name: fs}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		target string
+		want   []string
+	}{
+		{"1.20", nil},
+		{"1.24", []string{
+			"api-deprecated|PodSecurityPolicy/restricted: policy/v1beta1 PodSecurityPolicy is deprecated since Kubernetes 1.21 and removed in 1.25; no replacement is available",
+			"api-deprecated|HorizontalPodAutoscaler/prod/web: autoscaling/v2beta2 HorizontalPodAutoscaler is deprecated since Kubernetes 1.23 and removed in 1.26; use autoscaling/v2",
+		}},
+		{"1.29", []string{
+			"api-removed|PodSecurityPolicy/restricted: policy/v1beta1 PodSecurityPolicy was removed in Kubernetes 1.25; no replacement is available",
+			"api-removed|HorizontalPodAutoscaler/prod/web: autoscaling/v2beta2 HorizontalPodAutoscaler was removed in Kubernetes 1.26; use autoscaling/v2",
+			"api-deprecated|FlowSchema/fs: flowcontrol.apiserver.k8s.io/v1beta3 FlowSchema is deprecated since Kubernetes 1.29 and removed in 1.32; use flowcontrol.apiserver.k8s.io/v1",
+		}},
+	}
+	for _, tc := range cases {
+		c := &deprecatedAPIChecker{target: mustKubeVersion(tc.target)}
+		var got []string
+		for _, f := range c.checkManifests("apps/web", docs) {
+			got = append(got, f.Rule+"|"+f.Message)
+			wantLevel := levelWarning
+			if f.Rule == "api-removed" {
+				wantLevel = levelError
+			}
+			if f.Level != wantLevel || f.Resource == "" {
+				t.Errorf("unexpected finding %+v", f)
+			}
+		}
+		if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+			t.Errorf("target %s: got\n%s\nwant\n%s", tc.target, strings.Join(got, "\n"), strings.Join(tc.want, "\n"))
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Resource changes reported by diff mode.
+const (
+	changeAdded    = "added"
+	changeRemoved  = "removed"
+	changeModified = "modified"
+)
+
+// resourceChange is one rendered object that differs between base and head.
+type resourceChange struct {
+	Root string `json:"root"`
+	// Resource is the "apiVersion/kind/namespace/name" key of the object.
+	Resource string `json:"resource"`
+	Change   string `json:"change"`
+	// Diff is the unified YAML diff of the object.
+	Diff string `json:"diff"`
+}
+
+// manifestDiff compares the rendered output of roots at a base commit and at HEAD.
+type manifestDiff struct {
+	Base     string           `json:"base"`
+	Added    int              `json:"added"`
+	Removed  int              `json:"removed"`
+	Modified int              `json:"modified"`
+	Changes  []resourceChange `json:"changes"`
+	// Skipped lists roots not diffed because their base or head build failed.
+	Skipped []string `json:"skipped"`
+}
+
+// resourceKey identifies an object across builds as apiVersion/kind/namespace/name,
+// leaving out the namespace of cluster-scoped objects.
+func resourceKey(m manifest) string {
+	parts := []string{m.APIVersion, m.Kind}
+	if m.Namespace != "" {
+		parts = append(parts, m.Namespace)
+	}
+	return strings.Join(append(parts, m.Name), "/")
+}
+
+// runManifestDiff builds roots at the base commit of the current change set
+// in a temporary worktree and diffs the result against the HEAD build in head.
+func runManifestDiff(config Config, roots []string, head Summary, kustomizePath string, builder KustomizeBuilder) (*manifestDiff, error) {
+	cs := resolveChangeSet(config, loadGitHubEvent())
+	base, err := resolveBaseCommit(config.WorkingDir, cs)
+	if err != nil {
+		return nil, err
+	}
+	repoRoot, err := gitRepoRoot(config.WorkingDir)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("🔀 diff: building %d roots at base %s (%s)...", len(roots), shortSHA(base), cs)
+
+	worktree, cleanup, err := addDetachedWorktree(repoRoot, base)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	outDir, err := os.MkdirTemp("", "kustomize-base-out-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(outDir)
+
+	baseRoots := make([]string, len(roots))
+	for i, r := range roots {
+		baseRoots[i] = filepath.Join(worktree, filepath.FromSlash(r))
+	}
+	baseConf := config
+	baseConf.OutputDir = outDir
+	baseConf.FailFast = false
+	baseConf.ValidateSchemas = false
+	baseConf.KubeVersion = ""
+	baseConf.PolicyRules = nil
+	baseSummary := builder(baseRoots, baseConf, kustomizePath)
+
+	d := &manifestDiff{Base: base, Changes: []resourceChange{}, Skipped: []string{}}
+	for i, root := range roots {
+		if i >= len(baseSummary.Results) || i >= len(head.Results) {
+			break
+		}
+		baseRes, headRes := baseSummary.Results[i], head.Results[i]
+		if baseRes.Status != statusSuccess || headRes.Status != statusSuccess {
+			log.Printf("⚠️ diff: skipping %s (base: %s, head: %s).", displayRoot(root), baseRes.Status, headRes.Status)
+			d.Skipped = append(d.Skipped, root)
+			continue
+		}
+		baseDocs, err := readRenderedManifests(baseRes.OutputPath, config.OutputLayout)
+		if err != nil {
+			return nil, fmt.Errorf("read base output of %s: %v", displayRoot(root), err)
+		}
+		headDocs, err := readRenderedManifests(headRes.OutputPath, config.OutputLayout)
+		if err != nil {
+			return nil, fmt.Errorf("read output of %s: %v", displayRoot(root), err)
+		}
+		d.add(root, baseDocs, headDocs)
+	}
+	return d, nil
+}
+
+// readRenderedManifests parses a rendered output file, or every manifest file
+// of a split output directory, in any output format. A root without a
+// kustomization file at that commit has no output and renders nothing, as
+// does a missing split output directory.
+func readRenderedManifests(path, layout string) ([]manifest, error) {
+	if path == "" {
+		return nil, nil
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) && layout == layoutSplit {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return decodeRenderedFile(path)
+	}
+	var docs []manifest
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !isRenderedFileName(p) {
+			return err
+		}
+		parsed, err := decodeRenderedFile(p)
+		docs = append(docs, parsed...)
+		return err
+	})
+	return docs, err
+}
+
+// add records the per-resource changes of root between base and head.
+func (d *manifestDiff) add(root string, base, head []manifest) {
+	baseByKey := manifestsByKey(base)
+	headByKey := manifestsByKey(head)
+
+	keys := make([]string, 0, len(baseByKey)+len(headByKey))
+	for k := range baseByKey {
+		keys = append(keys, k)
+	}
+	for k := range headByKey {
+		if _, ok := baseByKey[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		before, inBase := baseByKey[k]
+		after, inHead := headByKey[k]
+		c := resourceChange{Root: root, Resource: k}
+		switch {
+		case !inBase:
+			c.Change = changeAdded
+			c.Diff = unifiedDiff("/dev/null", "b/"+k, "", after)
+			d.Added++
+		case !inHead:
+			c.Change = changeRemoved
+			c.Diff = unifiedDiff("a/"+k, "/dev/null", before, "")
+			d.Removed++
+		default:
+			c.Diff = unifiedDiff("a/"+k, "b/"+k, before, after)
+			if c.Diff == "" {
+				continue
+			}
+			c.Change = changeModified
+			d.Modified++
+		}
+		d.Changes = append(d.Changes, c)
+	}
+}
+
+// manifestsByKey renders every object as YAML with sorted keys, indexed by resourceKey.
+func manifestsByKey(docs []manifest) map[string]string {
+	out := make(map[string]string, len(docs))
+	for _, m := range docs {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(m.Object); err != nil {
+			continue
+		}
+		enc.Close()
+		out[resourceKey(m)] = buf.String()
+	}
+	return out
+}
+
+// patch concatenates the diffs of all changes.
+func (d *manifestDiff) patch() string {
+	var b strings.Builder
+	for _, c := range d.Changes {
+		b.WriteString(c.Diff)
+	}
+	return b.String()
+}
+
+// writeManifestDiff writes _diff.json and _diff.patch into outputDir.
+func writeManifestDiff(outputDir string, d *manifestDiff) error {
+	b, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, "_diff.json"), b, 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outputDir, "_diff.patch"), []byte(d.patch()), 0o644)
+}
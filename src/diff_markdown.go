@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// githubCommentLimit is the maximum length of a GitHub issue or PR comment.
+const githubCommentLimit = 65536
+
+// diffMarkdownReserve keeps room for the closing lines of a truncated comment.
+const diffMarkdownReserve = 512
+
+var changeIcons = map[string]string{
+	changeAdded:    "🟢",
+	changeRemoved:  "🔴",
+	changeModified: "🟡",
+}
+
+// renderDiffMarkdown renders d as a PR comment body of at most limit bytes:
+// a header with counts, then per root one collapsible block per changed
+// resource. Diffs that do not fit are cut with a "N more lines" marker and
+// the remaining resources are only counted.
+func renderDiffMarkdown(d *manifestDiff, limit int) string {
+	var b strings.Builder
+	b.WriteString("## Rendered manifest diff\n\n")
+	fmt.Fprintf(&b, "Compared against `%s`: **%d added**, **%d removed**, **%d modified** resources.\n\n", shortSHA(d.Base), d.Added, d.Removed, d.Modified)
+	if len(d.Skipped) > 0 {
+		var roots []string
+		for _, r := range d.Skipped {
+			roots = append(roots, markdownCode(displayRoot(r)))
+		}
+		fmt.Fprintf(&b, "⚠️ Not diffed because a build failed: %s\n\n", strings.Join(roots, ", "))
+	}
+	if len(d.Changes) == 0 {
+		b.WriteString("No changes to rendered resources.\n")
+		return b.String()
+	}
+
+	budget := limit - diffMarkdownReserve
+	root := ""
+	for i, c := range d.Changes {
+		var block strings.Builder
+		if i == 0 || c.Root != root {
+			fmt.Fprintf(&block, "### %s\n\n", markdownCode(displayRoot(c.Root)))
+			root = c.Root
+		}
+		fmt.Fprintf(&block, "<details>\n<summary>%s %s <code>%s</code></summary>\n\n", changeIcons[c.Change], c.Change, htmlEscape(c.Resource))
+		fence := codeFence(c.Diff)
+		open, closing := fence+"diff\n", fence+"\n\n</details>\n\n"
+
+		if b.Len()+block.Len()+len(open)+len(c.Diff)+len(closing) <= budget {
+			block.WriteString(open + c.Diff + closing)
+			b.WriteString(block.String())
+			continue
+		}
+
+		// Fit as many diff lines as possible, then stop.
+		lines := splitLines(c.Diff)
+		room := budget - b.Len() - block.Len() - len(open) - len(closing) - 64
+		shown := 0
+		var body strings.Builder
+		for _, l := range lines {
+			if body.Len()+len(l)+1 > room {
+				break
+			}
+			body.WriteString(l + "\n")
+			shown++
+		}
+		rest := len(d.Changes) - i
+		if shown > 0 {
+			block.WriteString(open + body.String())
+			fmt.Fprintf(&block, "… %d more lines\n", len(lines)-shown)
+			block.WriteString(closing)
+			b.WriteString(block.String())
+			rest--
+		}
+		if rest > 0 {
+			fmt.Fprintf(&b, "_…and %d more changed resources not shown, see `_diff.patch` in the build artifact._\n", rest)
+		}
+		break
+	}
+	return b.String()
+}
+
+// codeFence returns a backtick fence longer than any backtick run in s.
+func codeFence(s string) string {
+	longest, run := 0, 0
+	for _, r := range s {
+		if r == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	if longest < 3 {
+		return "```"
+	}
+	return strings.Repeat("`", longest+1)
+}
+
+// writeDiffMarkdown writes the PR comment body to _diff.md in outputDir and returns its path.
+func writeDiffMarkdown(outputDir string, d *manifestDiff) (string, error) {
+	path := filepath.Join(outputDir, "_diff.md")
+	return path, os.WriteFile(path, []byte(renderDiffMarkdown(d, githubCommentLimit)), 0o644)
+}
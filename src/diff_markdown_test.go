@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRenderDiffMarkdown(t *testing.T) {
+	d := &manifestDiff{
+		Base:     "0123456789abcdef",
+		Added:    1,
+		Modified: 1,
+		Changes: []resourceChange{
+			{Root: "apps/web", Resource: "v1/ConfigMap/prod/a", Change: changeModified, Diff: "--- a/x\n+++ b/x\n@@ -1 +1 @@\n-a\n+b\n"},
+			{Root: "apps/web", Resource: "v1/Service/prod/web", Change: changeAdded, Diff: "--- /dev/null\n+++ b/y\n@@ -0,0 +1 @@\n+y\n"},
+			{Root: "", Resource: "v1/Namespace/prod", Change: changeRemoved, Diff: "--- a/z\n+++ /dev/null\n@@ -1 +0,0 @@\n-z\n"},
+		},
+		Skipped: []string{"apps/broken"},
+	}
+
+	got := renderDiffMarkdown(d, githubCommentLimit)
+
+	for _, want := range []string{
+		"Compared against `0123456`: **1 added**, **0 removed**, **1 modified** resources.",
+		"⚠️ Not diffed because a build failed: `apps/broken`",
+		"### `apps/web`\n\n<details>\n<summary>🟡 modified <code>v1/ConfigMap/prod/a</code></summary>\n\n```diff\n--- a/x\n",
+		"<summary>🟢 added <code>v1/Service/prod/web</code></summary>",
+		"### `.`\n\n<details>\n<summary>🔴 removed",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+	if strings.Count(got, "### `apps/web`") != 1 {
+		t.Errorf("expected changes grouped under one root heading:\n%s", got)
+	}
+}
+
+func TestRenderDiffMarkdown_NoChanges(t *testing.T) {
+	got := renderDiffMarkdown(&manifestDiff{Base: "abc"}, githubCommentLimit)
+	if !strings.Contains(got, "No changes to rendered resources.") {
+		t.Errorf("unexpected body:\n%s", got)
+	}
+}
+
+func TestRenderDiffMarkdown_TruncatesToLimit(t *testing.T) {
+	var lines []string
+	for i := 0; i < 2000; i++ {
+		lines = append(lines, fmt.Sprintf("+line %04d with some padding to make it longer", i))
+	}
+	big := "--- /dev/null\n+++ b/x\n" + strings.Join(lines, "\n") + "\n"
+	d := &manifestDiff{Base: "abc", Added: 3}
+	for i := 0; i < 3; i++ {
+		d.Changes = append(d.Changes, resourceChange{Root: "apps", Resource: fmt.Sprintf("v1/ConfigMap/c%d", i), Change: changeAdded, Diff: big})
+	}
+
+	got := renderDiffMarkdown(d, githubCommentLimit)
+
+	if len(got) > githubCommentLimit {
+		t.Fatalf("body of %d bytes exceeds the comment limit", len(got))
+	}
+	if !strings.Contains(got, "more lines\n```\n\n</details>") {
+		t.Errorf("expected a truncated diff with a more-lines marker")
+	}
+	if !strings.Contains(got, "_…and 2 more changed resources not shown") {
+		t.Errorf("expected a note about resources not shown, got tail:\n%s", got[len(got)-300:])
+	}
+}
+
+func TestCodeFence(t *testing.T) {
+	if f := codeFence("plain"); f != "```" {
+		t.Errorf("got %q", f)
+	}
+	if f := codeFence("a ```` b"); f != "`````" {
+		t.Errorf("got %q", f)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func mustParseManifests(t *testing.T, s string) []manifest {
+	t.Helper()
+	docs, err := parseManifests([]byte(s))
+	if err != nil {
+		t.Fatalf("parse manifests: %v", err)
+	}
+	return docs
+}
+
+func TestManifestDiff_Add(t *testing.T) {
+	base := mustParseManifests(t, `apiVersion: v1
+kind: ConfigMap
+metadata: {name: settings, namespace: prod}
+data: {level: info}
+---
+apiVersion: v1
+kind: Namespace
+metadata: {name: old}
+---
+apiVersion: v1
+kind: Service
+metadata: {name: web, namespace: prod}
+spec: {ports: [{port: 80}]}
+`)
+	head := mustParseManifests(t, `apiVersion: v1
+kind: Service
+metadata: {namespace: prod, name: web}
+spec: {ports: [{port: 80}]}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: settings, namespace: prod}
+data: {level: debug}
+---
+apiVersion: v1
+kind: Namespace
+metadata: {name: new}
+`)
+
+	d := &manifestDiff{}
+	d.add("apps/web", base, head)
+
+	if d.Added != 1 || d.Removed != 1 || d.Modified != 1 {
+		t.Fatalf("unexpected counts: +%d -%d ~%d", d.Added, d.Removed, d.Modified)
+	}
+	var got []string
+	for _, c := range d.Changes {
+		got = append(got, c.Change+" "+c.Resource)
+		if c.Root != "apps/web" {
+			t.Errorf("unexpected root %q", c.Root)
+		}
+	}
+	want := []string{"modified v1/ConfigMap/prod/settings", "added v1/Namespace/new", "removed v1/Namespace/old"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got changes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	mod := d.Changes[0].Diff
+	for _, line := range []string{"--- a/v1/ConfigMap/prod/settings", "+++ b/v1/ConfigMap/prod/settings", "-  level: info", "+  level: debug"} {
+		if !strings.Contains(mod, line+"\n") {
+			t.Errorf("expected %q in diff:\n%s", line, mod)
+		}
+	}
+	if !strings.HasPrefix(d.Changes[1].Diff, "--- /dev/null\n+++ b/v1/Namespace/new\n") {
+		t.Errorf("unexpected added diff:\n%s", d.Changes[1].Diff)
+	}
+	if !strings.Contains(d.patch(), d.Changes[2].Diff) {
+		t.Error("expected patch to contain every change")
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+)
+
+// githubEvent holds the subset of the workflow event payload used by the action.
+type githubEvent struct {
+	PullRequest *struct {
+		Base struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"base"`
+	} `json:"pull_request"`
+
+	// Push payload fields.
+	Before string `json:"before"`
+	After  string `json:"after"`
+	Forced bool   `json:"forced"`
+}
+
+// loadGitHubEvent reads the payload referenced by GITHUB_EVENT_PATH.
+// A missing or unreadable payload yields an empty event.
+func loadGitHubEvent() githubEvent {
+	var ev githubEvent
+	path := os.Getenv("GITHUB_EVENT_PATH")
+	if path == "" {
+		return ev
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ev
+	}
+	_ = json.Unmarshal(b, &ev)
+	return ev
+}
+
+// changeSet describes which git range changed-only mode inspects.
+type changeSet struct {
+	// BaseRef, when set, diffs merge-base(BaseRef, HEAD)..HEAD.
+	BaseRef string
+	// Before and After, when set, diff the range covered by a push event.
+	Before string
+	After  string
+	Forced bool
+}
+
+func (cs changeSet) String() string {
+	if cs.BaseRef != "" {
+		return "changes against base " + cs.BaseRef
+	}
+	if cs.Before != "" {
+		s := "push " + shortSHA(cs.Before) + ".." + shortSHA(cs.After)
+		if cs.Forced {
+			s += " (forced)"
+		}
+		return s
+	}
+	return "last commit"
+}
+
+// resolveChangeSet picks the diff range: an explicit base-ref input wins,
+// then GITHUB_BASE_REF, then the pull_request payload, then the before/after
+// SHAs of a push payload. Without any of those the last commit
+// (HEAD~1..HEAD) is used.
+func resolveChangeSet(conf Config, ev githubEvent) changeSet {
+	if ref := strings.TrimSpace(conf.BaseRef); ref != "" {
+		return changeSet{BaseRef: ref}
+	}
+	if ref := strings.TrimSpace(os.Getenv("GITHUB_BASE_REF")); ref != "" {
+		return changeSet{BaseRef: ref}
+	}
+	if ev.PullRequest != nil {
+		if ev.PullRequest.Base.SHA != "" {
+			return changeSet{BaseRef: ev.PullRequest.Base.SHA}
+		}
+		if ev.PullRequest.Base.Ref != "" {
+			return changeSet{BaseRef: ev.PullRequest.Base.Ref}
+		}
+	}
+	if ev.Before != "" && ev.After != "" {
+		// A new branch has no previous head; only its last commit can be diffed.
+		if isZeroSHA(ev.Before) {
+			log.Println("ℹ️ push created a new branch (before is all zeros); using the last commit.")
+			return changeSet{}
+		}
+		return changeSet{Before: ev.Before, After: ev.After, Forced: ev.Forced}
+	}
+	return changeSet{}
+}
+
+func isZeroSHA(sha string) bool {
+	return strings.Trim(sha, "0") == ""
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
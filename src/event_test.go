@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadGitHubEvent_ReadsPullRequestBase(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "event.json")
+	if err := os.WriteFile(p, []byte(`{"pull_request":{"base":{"ref":"main","sha":"abc123"}}}`), 0o644); err != nil {
+		t.Fatalf("write event: %v", err)
+	}
+	t.Setenv("GITHUB_EVENT_PATH", p)
+
+	ev := loadGitHubEvent()
+	if ev.PullRequest == nil {
+		t.Fatalf("expected pull_request to be parsed")
+	}
+	if ev.PullRequest.Base.Ref != "main" || ev.PullRequest.Base.SHA != "abc123" {
+		t.Fatalf("unexpected base: %+v", ev.PullRequest.Base)
+	}
+}
+
+func TestLoadGitHubEvent_MissingPayloadIsEmpty(t *testing.T) {
+	t.Setenv("GITHUB_EVENT_PATH", filepath.Join(t.TempDir(), "missing.json"))
+	ev := loadGitHubEvent()
+	if ev.PullRequest != nil {
+		t.Fatalf("expected empty event, got %+v", ev)
+	}
+}
+
+func TestResolveChangeSet_Precedence(t *testing.T) {
+	var pr githubEvent
+	if err := json.Unmarshal([]byte(`{"pull_request":{"base":{"ref":"main","sha":"abc123"}}}`), &pr); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		envBase string
+		ev      githubEvent
+		want    string
+	}{
+		{name: "explicit input wins", input: "release", envBase: "main", ev: pr, want: "release"},
+		{name: "GITHUB_BASE_REF", envBase: "develop", ev: pr, want: "develop"},
+		{name: "payload base sha", ev: pr, want: "abc123"},
+		{name: "no base falls back to last commit", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GITHUB_BASE_REF", tt.envBase)
+			cs := resolveChangeSet(Config{BaseRef: tt.input}, tt.ev)
+			if cs.BaseRef != tt.want {
+				t.Fatalf("expected base %q, got %q", tt.want, cs.BaseRef)
+			}
+		})
+	}
+}
+
+func TestResolveChangeSet_PushPayload(t *testing.T) {
+	t.Setenv("GITHUB_BASE_REF", "")
+
+	ev := githubEvent{Before: "1111111111", After: "2222222222"}
+	cs := resolveChangeSet(Config{}, ev)
+	if cs.Before != "1111111111" || cs.After != "2222222222" {
+		t.Fatalf("expected push range, got %+v", cs)
+	}
+
+	ev = githubEvent{Before: "0000000000000000000000000000000000000000", After: "2222222222"}
+	cs = resolveChangeSet(Config{}, ev)
+	if cs != (changeSet{}) {
+		t.Fatalf("expected last-commit fallback for a new branch, got %+v", cs)
+	}
+
+	ev = githubEvent{Before: "1111111111", After: "2222222222", Forced: true}
+	cs = resolveChangeSet(Config{BaseRef: "main"}, ev)
+	if cs.BaseRef != "main" || cs.Before != "" {
+		t.Fatalf("expected explicit base-ref to win over push payload, got %+v", cs)
+	}
+}
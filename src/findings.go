@@ -0,0 +1,115 @@
+package main
+
+import "log"
+
+// Finding severities, matching SARIF result levels.
+const (
+	levelError   = "error"
+	levelWarning = "warning"
+	levelNote    = "note"
+)
+
+// Finding is a problem detected in a root's sources or rendered output.
+type Finding struct {
+	// Rule identifies the check that produced the finding, e.g. "build-failed".
+	Rule  string `json:"rule"`
+	Level string `json:"level"`
+	// Message describes the problem in one line.
+	Message string `json:"message"`
+	// File is the slash-separated, repo-relative file the finding points at.
+	// Empty means the root's kustomization file.
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+	// Resource identifies the rendered object, see manifest.ResourceID.
+	Resource string `json:"resource,omitempty"`
+}
+
+// findingRules describes every rule a Finding can carry.
+var findingRules = map[string]string{
+	"build-failed":    "kustomize build failed",
+	"build-timed-out": "kustomize build timed out",
+	"schema-invalid":  "rendered object does not match its schema",
+	"api-deprecated":  "rendered object uses an API deprecated in the target Kubernetes version",
+	"api-removed":     "rendered object uses an API removed in the target Kubernetes version",
+}
+
+// ruleDescription returns the description of a finding rule, or the rule itself if unknown.
+func ruleDescription(id string) string {
+	if d, ok := findingRules[id]; ok {
+		return d
+	}
+	for _, r := range policyRules {
+		if r.ID == id {
+			return r.Description
+		}
+	}
+	return id
+}
+
+// buildFindings returns the findings of r, including one for a failed or
+// timed-out build.
+func buildFindings(r RootResult) []Finding {
+	var out []Finding
+	switch r.Status {
+	case statusFailed:
+		out = append(out, Finding{Rule: "build-failed", Level: levelError, Message: annotationMessage(r)})
+	case statusTimedOut:
+		out = append(out, Finding{Rule: "build-timed-out", Level: levelError, Message: annotationMessage(r)})
+	}
+	return append(out, r.Findings...)
+}
+
+// countFindings returns how many findings were produced by rule.
+func countFindings(findings []Finding, rule string) int {
+	n := 0
+	for _, f := range findings {
+		if f.Rule == rule {
+			n++
+		}
+	}
+	return n
+}
+
+// countPolicyViolations returns how many findings were produced by policy rules.
+func countPolicyViolations(findings []Finding) int {
+	n := 0
+	for _, f := range findings {
+		if isPolicyRule(f.Rule) {
+			n++
+		}
+	}
+	return n
+}
+
+// newManifestCheckers sets up the checks on rendered manifests enabled in conf.
+func newManifestCheckers(conf Config) []manifestChecker {
+	var checkers []manifestChecker
+	if conf.ValidateSchemas {
+		store, err := newSchemaStore(conf.SchemaDir, conf.WorkingDir, []string{".git", conf.OutputDir})
+		if err != nil {
+			log.Printf("⚠️ Schema validation disabled: %v", err)
+		} else {
+			log.Printf("📐 Validating rendered manifests against %d CRD schemas and schema-dir %q.", len(store.crd), conf.SchemaDir)
+			checkers = append(checkers, &schemaChecker{store: store})
+		}
+	}
+	if conf.KubeVersion != "" {
+		target, err := parseKubeVersion(conf.KubeVersion)
+		if err != nil {
+			log.Printf("⚠️ Deprecated API detection disabled: %v", err)
+		} else {
+			log.Printf("📐 Checking rendered manifests for APIs deprecated or removed in Kubernetes %s.", target)
+			checkers = append(checkers, &deprecatedAPIChecker{target: target})
+		}
+	}
+	if len(conf.PolicyRules) > 0 {
+		rules, err := selectPolicyRules(conf.PolicyRules)
+		if err != nil {
+			log.Printf("⚠️ Policy checks disabled: %v", err)
+		} else {
+			log.Printf("📐 Applying %d policy rules to rendered manifests.", len(rules))
+			checkers = append(checkers, &policyChecker{rules: rules})
+		}
+	}
+	return checkers
+}
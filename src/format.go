@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats selected by the output-format input.
+const (
+	// formatYAML keeps the multi-document YAML stream kustomize prints.
+	formatYAML = "yaml"
+	// formatJSON writes a single v1 List object as indented JSON.
+	formatJSON = "json"
+	// formatNDJSON writes one compact JSON object per line.
+	formatNDJSON = "ndjson"
+	// formatYAMLList writes a single v1 List object as YAML.
+	formatYAMLList = "yaml-list"
+)
+
+func isOutputFormat(format string) bool {
+	switch format {
+	case "", formatYAML, formatJSON, formatNDJSON, formatYAMLList:
+		return true
+	}
+	return false
+}
+
+// formatExt returns the file extension of rendered output in format.
+func formatExt(format string) string {
+	switch format {
+	case formatJSON:
+		return ".json"
+	case formatNDJSON:
+		return ".ndjson"
+	}
+	return ".yaml"
+}
+
+// withFormatExt replaces the YAML extension of name with the one of format.
+func withFormatExt(name, format string) string {
+	ext := filepath.Ext(name)
+	if ext != ".yaml" && ext != ".yml" {
+		return name
+	}
+	if format == "" || format == formatYAML || format == formatYAMLList {
+		return name
+	}
+	return strings.TrimSuffix(name, ext) + formatExt(format)
+}
+
+// isManifestFileName reports whether name has the extension of rendered output in format.
+func isManifestFileName(name, format string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	if formatExt(format) == ".yaml" {
+		return ext == ".yaml" || ext == ".yml"
+	}
+	return ext == formatExt(format)
+}
+
+// isRenderedFileName reports whether name has the extension of any output format.
+func isRenderedFileName(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml", ".json", ".ndjson":
+		return true
+	}
+	return false
+}
+
+// convertRendered re-encodes the kustomize output in format. YAML output is
+// passed through unchanged.
+func convertRendered(rendered []byte, docs []manifest, parseErr error, format string) ([]byte, error) {
+	if format == "" || format == formatYAML {
+		return rendered, nil
+	}
+	if parseErr != nil {
+		return nil, fmt.Errorf("cannot convert unparseable output to %s: %v", format, parseErr)
+	}
+	return encodeManifests(docs, format)
+}
+
+// encodeManifests encodes docs in format.
+func encodeManifests(docs []manifest, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case formatJSON:
+		list := struct {
+			APIVersion string                   `json:"apiVersion"`
+			Kind       string                   `json:"kind"`
+			Items      []map[string]interface{} `json:"items"`
+		}{APIVersion: "v1", Kind: "List", Items: []map[string]interface{}{}}
+		for _, d := range docs {
+			list.Items = append(list.Items, d.Object)
+		}
+		b, err := json.MarshalIndent(list, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+	case formatNDJSON:
+		for _, d := range docs {
+			b, err := encodeManifest(d, format)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(b)
+		}
+	case formatYAMLList:
+		items := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, d := range docs {
+			items.Content = append(items.Content, manifestYAMLNode(d))
+		}
+		list := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Value: "apiVersion"}, {Kind: yaml.ScalarNode, Value: "v1"},
+			{Kind: yaml.ScalarNode, Value: "kind"}, {Kind: yaml.ScalarNode, Value: "List"},
+			{Kind: yaml.ScalarNode, Value: "items"}, items,
+		}}
+		return encodeManifestYAML(manifest{node: list})
+	default:
+		for i, d := range docs {
+			if i > 0 {
+				buf.WriteString("---\n")
+			}
+			b, err := encodeManifest(d, format)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(b)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeManifest encodes the single object doc in format without a List
+// wrapper: indented JSON for json, one line for ndjson and YAML otherwise.
+func encodeManifest(doc manifest, format string) ([]byte, error) {
+	var b []byte
+	var err error
+	switch format {
+	case formatJSON:
+		b, err = json.MarshalIndent(doc.Object, "", "  ")
+	case formatNDJSON:
+		b, err = json.Marshal(doc.Object)
+	default:
+		return encodeManifestYAML(doc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// manifestYAMLNode returns the mapping node of d, keeping its field order when known.
+func manifestYAMLNode(d manifest) *yaml.Node {
+	if d.node != nil && d.node.Kind == yaml.DocumentNode && len(d.node.Content) == 1 {
+		return d.node.Content[0]
+	}
+	var n yaml.Node
+	if err := n.Encode(d.Object); err != nil {
+		return &yaml.Node{Kind: yaml.MappingNode}
+	}
+	return &n
+}
+
+// decodeRenderedFile parses rendered output written in any output format.
+// List objects are expanded into their items.
+func decodeRenderedFile(path string) ([]manifest, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var docs []manifest
+	if strings.EqualFold(filepath.Ext(path), ".ndjson") {
+		sc := bufio.NewScanner(bytes.NewReader(b))
+		sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+		for sc.Scan() {
+			line := bytes.TrimSpace(sc.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			parsed, err := parseManifests(line)
+			if err != nil {
+				return docs, err
+			}
+			docs = append(docs, parsed...)
+		}
+		if err := sc.Err(); err != nil {
+			return docs, err
+		}
+	} else if docs, err = parseManifests(b); err != nil {
+		return docs, err
+	}
+	return expandLists(docs), nil
+}
+
+// expandLists replaces v1 List objects by their items.
+func expandLists(docs []manifest) []manifest {
+	var out []manifest
+	for _, d := range docs {
+		if d.APIVersion != "v1" || d.Kind != "List" {
+			out = append(out, d)
+			continue
+		}
+		items, _ := d.Object["items"].([]interface{})
+		for _, it := range items {
+			if obj, ok := it.(map[string]interface{}); ok {
+				out = append(out, newManifest(obj, nil))
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const formatTestManifests = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+  namespace: prod
+data:
+  key: value
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: prod
+`
+
+func TestEncodeManifests_JSONList(t *testing.T) {
+	b, err := encodeManifests(mustParseManifests(t, formatTestManifests), formatJSON)
+	if err != nil {
+		t.Fatalf("encodeManifests: %v", err)
+	}
+	var list struct {
+		Kind  string                   `json:"kind"`
+		Items []map[string]interface{} `json:"items"`
+	}
+	if err := json.Unmarshal(b, &list); err != nil {
+		t.Fatalf("invalid JSON %q: %v", b, err)
+	}
+	if list.Kind != "List" || len(list.Items) != 2 || list.Items[1]["kind"] != "Namespace" {
+		t.Errorf("unexpected list %s", b)
+	}
+	if !strings.HasPrefix(string(b), "{\n  \"apiVersion\": \"v1\",\n  \"kind\": \"List\",") {
+		t.Errorf("expected indented List header, got %s", b)
+	}
+}
+
+func TestEncodeManifests_NDJSON(t *testing.T) {
+	b, err := encodeManifests(mustParseManifests(t, formatTestManifests), formatNDJSON)
+	if err != nil {
+		t.Fatalf("encodeManifests: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one line per object, got %q", b)
+	}
+	if lines[1] != `{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"prod"}}` {
+		t.Errorf("unexpected line %q", lines[1])
+	}
+}
+
+func TestEncodeManifests_YAMLListKeepsFieldOrder(t *testing.T) {
+	b, err := encodeManifests(mustParseManifests(t, formatTestManifests), formatYAMLList)
+	if err != nil {
+		t.Fatalf("encodeManifests: %v", err)
+	}
+	want := `apiVersion: v1
+kind: List
+items:
+  - apiVersion: v1
+    kind: ConfigMap
+    metadata:
+      name: a
+      namespace: prod
+    data:
+      key: value
+  - apiVersion: v1
+    kind: Namespace
+    metadata:
+      name: prod
+`
+	if string(b) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b, want)
+	}
+}
+
+func TestConvertRendered(t *testing.T) {
+	raw := []byte("# kept as is\n" + formatTestManifests)
+	if b, err := convertRendered(raw, nil, nil, formatYAML); err != nil || string(b) != string(raw) {
+		t.Errorf("expected yaml output to pass through, got %q (%v)", b, err)
+	}
+	_, parseErr := parseManifests([]byte("a: [\n"))
+	if _, err := convertRendered([]byte("a: [\n"), nil, parseErr, formatJSON); err == nil || !strings.Contains(err.Error(), "unparseable") {
+		t.Errorf("expected unparseable output to fail conversion, got %v", err)
+	}
+}
+
+func TestWithFormatExt(t *testing.T) {
+	for _, tc := range []struct{ name, format, want string }{
+		{"apps_web_kustomization.yaml", formatYAML, "apps_web_kustomization.yaml"},
+		{"apps_web_kustomization.yml", formatJSON, "apps_web_kustomization.json"},
+		{"apps_web_kustomization.yaml", formatNDJSON, "apps_web_kustomization.ndjson"},
+		{"apps_web_kustomization.yaml", formatYAMLList, "apps_web_kustomization.yaml"},
+		{"apps_web_Kustomization", formatJSON, "apps_web_Kustomization"},
+	} {
+		if got := withFormatExt(tc.name, tc.format); got != tc.want {
+			t.Errorf("withFormatExt(%q, %q) = %q, want %q", tc.name, tc.format, got, tc.want)
+		}
+	}
+}
+
+func TestDecodeRenderedFile_ReadsEveryFormat(t *testing.T) {
+	dir := t.TempDir()
+	docs := mustParseManifests(t, formatTestManifests)
+	for _, format := range []string{formatYAML, formatJSON, formatNDJSON, formatYAMLList} {
+		b, err := encodeManifests(docs, format)
+		if err != nil {
+			t.Fatalf("%s: encodeManifests: %v", format, err)
+		}
+		p := filepath.Join(dir, format+formatExt(format))
+		mustWriteFile(t, p, string(b))
+
+		got, err := decodeRenderedFile(p)
+		if err != nil {
+			t.Fatalf("%s: decodeRenderedFile: %v", format, err)
+		}
+		if len(got) != 2 || got[0].ResourceID() != "ConfigMap/prod/a" || got[1].ResourceID() != "Namespace/prod" {
+			t.Errorf("%s: unexpected objects %+v", format, got)
+		}
+		if got[0].Object["data"].(map[string]interface{})["key"] != "value" {
+			t.Errorf("%s: lost object content: %+v", format, got[0].Object)
+		}
+	}
+}
+
+func TestBuildKustomizations_JSONFormat(t *testing.T) {
+	tmpDir := t.TempDir()
+	outDir := filepath.Join(tmpDir, "out")
+	app := filepath.Join(tmpDir, "app")
+	for _, d := range []string{outDir, app} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeKustomizationYAML(t, app)
+
+	conf := Config{OutputDir: outDir, LoadRestrictor: "LoadRestrictionsNone", Parallelism: 1, OutputFormat: formatJSON}
+	summary := buildKustomizations([]string{app}, conf, "kustomize", fakeRenderRunner(formatTestManifests))
+
+	res := summary.Results[0]
+	want := filepath.Join(outDir, sanitizeOutName(app)+"_kustomization.json")
+	if res.Status != statusSuccess || res.OutputPath != want {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if n, err := countManifestFiles(outDir, formatJSON); err != nil || n != 1 {
+		t.Errorf("expected 1 JSON manifest, got %d (%v)", n, err)
+	}
+	if n, _ := countManifestFiles(outDir, formatYAML); n != 0 {
+		t.Errorf("expected no YAML output, got %d files", n)
+	}
+	docs, err := readRenderedManifests(res.OutputPath, layoutFlat)
+	if err != nil || len(docs) != 2 {
+		t.Errorf("expected JSON output to read back as 2 objects, got %d (%v)", len(docs), err)
+	}
+}
+
+func TestBuildKustomizations_SplitWritesBareObjects(t *testing.T) {
+	tmpDir := t.TempDir()
+	outDir := filepath.Join(tmpDir, "out")
+	app := filepath.Join(tmpDir, "app")
+	for _, d := range []string{outDir, app} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeKustomizationYAML(t, app)
+
+	want := map[string]string{
+		formatJSON:     "{\n  \"apiVersion\": \"v1\",\n  \"kind\": \"Namespace\",\n  \"metadata\": {\n    \"name\": \"prod\"\n  }\n}\n",
+		formatNDJSON:   "{\"apiVersion\":\"v1\",\"kind\":\"Namespace\",\"metadata\":{\"name\":\"prod\"}}\n",
+		formatYAMLList: "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: prod\n",
+	}
+	for format, namespace := range want {
+		out := filepath.Join(outDir, format)
+		conf := Config{OutputDir: out, LoadRestrictor: "LoadRestrictionsNone", Parallelism: 1, OutputLayout: layoutSplit, OutputFormat: format}
+		summary := buildKustomizations([]string{app}, conf, "kustomize", fakeRenderRunner(formatTestManifests))
+
+		splitDir := summary.Results[0].OutputPath
+		ext := formatExt(format)
+		if !fileExists(filepath.Join(splitDir, "prod", "configmap-a"+ext)) {
+			t.Errorf("%s: expected prod/configmap-a%s below %s", format, ext, splitDir)
+		}
+		if b, err := os.ReadFile(filepath.Join(splitDir, "_cluster", "namespace-prod"+ext)); err != nil || string(b) != namespace {
+			t.Errorf("%s: expected the bare object, got %q (%v)", format, b, err)
+		}
+		if n, _ := countManifestFiles(out, format); n != 2 {
+			t.Errorf("%s: expected 2 manifests, got %d", format, n)
+		}
+	}
+}
+
+func TestCountManifestFiles_SkipsReports(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a_kustomization.json", "_summary.json", "report.json", "a_kustomization-err.yaml"} {
+		mustWriteFile(t, filepath.Join(dir, name), "{}")
+	}
+	if n, err := countManifestFiles(dir, formatJSON, "_summary.json", "report.json"); err != nil || n != 1 {
+		t.Errorf("expected 1 manifest, got %d (%v)", n, err)
+	}
+}
+
+func TestEncodeManifests_KeepsUnquotedDatesAsStrings(t *testing.T) {
+	in := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n  annotations:\n    deploy-date: 2024-01-01\n    at: 2024-01-01T10:00:00Z\n"
+	docs := mustParseManifests(t, in)
+	for _, format := range []string{formatJSON, formatNDJSON} {
+		b, err := encodeManifests(docs, format)
+		if err != nil {
+			t.Fatalf("%s: encodeManifests: %v", format, err)
+		}
+		if !strings.Contains(string(b), `"deploy-date": "2024-01-01"`) && !strings.Contains(string(b), `"deploy-date":"2024-01-01"`) {
+			t.Errorf("%s: expected the date to stay a string, got %s", format, b)
+		}
+		if !strings.Contains(string(b), `"2024-01-01T10:00:00Z"`) {
+			t.Errorf("%s: expected the timestamp to stay as written, got %s", format, b)
+		}
+	}
+	// The YAML encoding is unchanged: the date stays unquoted.
+	if b, _ := encodeManifests(docs, formatYAML); string(b) != in {
+		t.Errorf("expected YAML to round-trip, got:\n%s", b)
+	}
+}
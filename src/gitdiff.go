@@ -3,10 +3,24 @@ package main
 import (
 	"bytes"
 	"fmt"
+	"log"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 )
 
+// getChangedFiles returns the repo-root relative paths changed in the range described by cs.
+func getChangedFiles(startDir string, cs changeSet) ([]string, error) {
+	if cs.BaseRef != "" {
+		return getChangedFilesSinceBase(startDir, cs.BaseRef)
+	}
+	if cs.Before != "" {
+		return getChangedFilesBetween(startDir, cs.Before, cs.After, cs.Forced)
+	}
+	return getChangedFilesLastCommit(startDir)
+}
+
 func getChangedFilesLastCommit(startDir string) ([]string, error) {
 	repoRoot, err := gitRepoRoot(startDir)
 	if err != nil {
@@ -15,8 +29,134 @@ func getChangedFilesLastCommit(startDir string) ([]string, error) {
 	if err := verifyHasParentCommit(repoRoot); err != nil {
 		return nil, err
 	}
+	return diffChangedFiles(repoRoot, "HEAD~1..HEAD")
+}
+
+// getChangedFilesSinceBase diffs merge-base(baseRef, HEAD)..HEAD so that every
+// commit of a pull request is taken into account, not only the last one.
+func getChangedFilesSinceBase(startDir, baseRef string) ([]string, error) {
+	repoRoot, err := gitRepoRoot(startDir)
+	if err != nil {
+		return nil, err
+	}
+	base, err := mergeBase(repoRoot, baseRef)
+	if err != nil {
+		return nil, err
+	}
+	return diffChangedFiles(repoRoot, base+"..HEAD")
+}
+
+// mergeBase returns the commit where HEAD forked from baseRef.
+func mergeBase(repoRoot, baseRef string) (string, error) {
+	base, err := resolveCommit(repoRoot, baseRef)
+	if err != nil {
+		return "", err
+	}
+	out, err := gitOutput(repoRoot, "merge-base", base, "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("cannot determine changed files against base %q: no merge-base with HEAD. The history is likely too shallow; use actions/checkout with fetch-depth: 0. Original error: %w", baseRef, err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// resolveBaseCommit returns the commit that the range described by cs starts
+// from, i.e. the state HEAD is compared against.
+func resolveBaseCommit(startDir string, cs changeSet) (string, error) {
+	repoRoot, err := gitRepoRoot(startDir)
+	if err != nil {
+		return "", err
+	}
+	if cs.BaseRef != "" {
+		return mergeBase(repoRoot, cs.BaseRef)
+	}
+	if cs.Before != "" && commitExists(repoRoot, cs.Before) {
+		return gitRevParseCommit(repoRoot, cs.Before)
+	}
+	if cs.Before != "" && !cs.Forced {
+		return "", fmt.Errorf("cannot determine base commit for push: commit %s (event 'before') not available. Ensure actions/checkout uses fetch-depth: 0", cs.Before)
+	}
+	if err := verifyHasParentCommit(repoRoot); err != nil {
+		return "", err
+	}
+	return gitRevParseCommit(repoRoot, "HEAD~1")
+}
+
+func gitRevParseCommit(repoRoot, rev string) (string, error) {
+	out, err := gitOutput(repoRoot, "rev-parse", "--verify", rev+"^{commit}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// addDetachedWorktree checks out commit into a new temporary worktree of the
+// repository. The returned cleanup removes it again.
+func addDetachedWorktree(repoRoot, commit string) (string, func(), error) {
+	tmp, err := os.MkdirTemp("", "kustomize-base-")
+	if err != nil {
+		return "", nil, err
+	}
+	dir := filepath.Join(tmp, "worktree")
+	if _, err := gitOutput(repoRoot, "worktree", "add", "--detach", dir, commit); err != nil {
+		os.RemoveAll(tmp)
+		return "", nil, err
+	}
+	cleanup := func() {
+		if _, err := gitOutput(repoRoot, "worktree", "remove", "--force", dir); err != nil {
+			log.Printf("⚠️ Could not remove worktree %s: %v", dir, err)
+		}
+		os.RemoveAll(tmp)
+	}
+	return dir, cleanup, nil
+}
+
+// getChangedFilesBetween diffs before..after of a push event so that every
+// commit of a batched push is taken into account. For force pushes the old
+// head is usually not fetched; in that case the last commit is used instead.
+func getChangedFilesBetween(startDir, before, after string, forced bool) ([]string, error) {
+	repoRoot, err := gitRepoRoot(startDir)
+	if err != nil {
+		return nil, err
+	}
+	if after == "" {
+		after = "HEAD"
+	}
+	if !commitExists(repoRoot, before) {
+		if forced {
+			log.Printf("⚠️ Force push: previous head %s is not available locally; falling back to the last commit.", shortSHA(before))
+			return getChangedFilesLastCommit(startDir)
+		}
+		return nil, fmt.Errorf("cannot determine changed files for push: commit %s (event 'before') not available. Ensure actions/checkout uses fetch-depth: 0", before)
+	}
+	if !commitExists(repoRoot, after) {
+		return nil, fmt.Errorf("cannot determine changed files for push: commit %s (event 'after') not available in the local clone", after)
+	}
+	return diffChangedFiles(repoRoot, before+".."+after)
+}
+
+func commitExists(repoRoot, sha string) bool {
+	_, err := gitOutput(repoRoot, "cat-file", "-e", sha+"^{commit}")
+	return err == nil
+}
+
+// resolveCommit resolves ref to a commit SHA, preferring the remote-tracking
+// branch (origin/<ref>) since CI checkouts rarely have local branches.
+func resolveCommit(repoRoot, ref string) (string, error) {
+	candidates := []string{ref}
+	if !strings.HasPrefix(ref, "origin/") && !strings.HasPrefix(ref, "refs/") {
+		candidates = []string{"origin/" + ref, ref}
+	}
+	for _, c := range candidates {
+		out, err := gitOutput(repoRoot, "rev-parse", "--verify", "--quiet", c+"^{commit}")
+		if err == nil {
+			return strings.TrimSpace(out), nil
+		}
+	}
+	return "", fmt.Errorf("cannot determine changed files: base ref %q is not available in the local clone. Ensure actions/checkout uses fetch-depth: 0 or fetch the base explicitly (git fetch origin %s)", ref, ref)
+}
 
-	out, err := gitOutput(repoRoot, "diff", "--name-only", "--diff-filter=ACMRD", "HEAD~1..HEAD")
+func diffChangedFiles(repoRoot, rangeSpec string) ([]string, error) {
+	out, err := gitOutput(repoRoot, "diff", "--name-only", "--diff-filter=ACMRD", rangeSpec)
 	if err != nil {
 		return nil, err
 	}
@@ -102,6 +102,166 @@ func TestGetChangedFilesLastCommit_IncludesDeletedFiles(t *testing.T) {
 	}
 }
 
+func TestGetChangedFilesSinceBase_IncludesEveryCommitSinceMergeBase(t *testing.T) {
+	repoDir := t.TempDir()
+
+	runGit(t, repoDir, "init", "-b", "main")
+	runGit(t, repoDir, "config", "user.email", "test@example.com")
+	runGit(t, repoDir, "config", "user.name", "Test User")
+
+	mustWriteFile(t, filepath.Join(repoDir, "apps/a/kustomization.yaml"), "resources: []\n")
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-m", "base")
+
+	// Two commits on the feature branch
+	runGit(t, repoDir, "checkout", "-b", "feature")
+	mustWriteFile(t, filepath.Join(repoDir, "apps/a/first.yaml"), "first")
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-m", "first")
+	mustWriteFile(t, filepath.Join(repoDir, "apps/b/second.yaml"), "second")
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-m", "second")
+
+	// main moves on independently; its changes must not show up
+	runGit(t, repoDir, "checkout", "main")
+	mustWriteFile(t, filepath.Join(repoDir, "apps/c/main-only.yaml"), "main")
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-m", "main only")
+	runGit(t, repoDir, "checkout", "feature")
+
+	changed, err := getChangedFilesSinceBase(repoDir, "main")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !contains(changed, "apps/a/first.yaml") || !contains(changed, "apps/b/second.yaml") {
+		t.Fatalf("expected changes from both feature commits, got %v", changed)
+	}
+	if contains(changed, "apps/c/main-only.yaml") {
+		t.Fatalf("did not expect changes made on base after the merge-base, got %v", changed)
+	}
+}
+
+func TestGetChangedFilesSinceBase_MissingBaseReturnsHelpfulError(t *testing.T) {
+	repoDir := t.TempDir()
+
+	runGit(t, repoDir, "init")
+	runGit(t, repoDir, "config", "user.email", "test@example.com")
+	runGit(t, repoDir, "config", "user.name", "Test User")
+
+	mustWriteFile(t, filepath.Join(repoDir, "README.md"), "hello")
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-m", "initial")
+
+	_, err := getChangedFilesSinceBase(repoDir, "does-not-exist")
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "does-not-exist") {
+		t.Fatalf("expected error to mention the base ref, got %q", msg)
+	}
+	if !strings.Contains(msg, "fetch-depth") {
+		t.Fatalf("expected error to mention fetch-depth, got %q", msg)
+	}
+}
+
+func TestGetChangedFilesBetween_CoversEveryCommitOfPush(t *testing.T) {
+	repoDir := t.TempDir()
+
+	runGit(t, repoDir, "init")
+	runGit(t, repoDir, "config", "user.email", "test@example.com")
+	runGit(t, repoDir, "config", "user.name", "Test User")
+
+	mustWriteFile(t, filepath.Join(repoDir, "README.md"), "hello")
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-m", "initial")
+	before := gitRevParse(t, repoDir, "HEAD")
+
+	mustWriteFile(t, filepath.Join(repoDir, "apps/a/one.yaml"), "one")
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-m", "one")
+	mustWriteFile(t, filepath.Join(repoDir, "apps/b/two.yaml"), "two")
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-m", "two")
+	after := gitRevParse(t, repoDir, "HEAD")
+
+	changed, err := getChangedFilesBetween(repoDir, before, after, false)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !contains(changed, "apps/a/one.yaml") || !contains(changed, "apps/b/two.yaml") {
+		t.Fatalf("expected files from both pushed commits, got %v", changed)
+	}
+}
+
+func TestGetChangedFilesBetween_ForcePushWithUnknownBeforeFallsBackToLastCommit(t *testing.T) {
+	repoDir := t.TempDir()
+
+	runGit(t, repoDir, "init")
+	runGit(t, repoDir, "config", "user.email", "test@example.com")
+	runGit(t, repoDir, "config", "user.name", "Test User")
+
+	mustWriteFile(t, filepath.Join(repoDir, "apps/a/one.yaml"), "one")
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-m", "one")
+	mustWriteFile(t, filepath.Join(repoDir, "apps/b/two.yaml"), "two")
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-m", "two")
+
+	unknown := "0123456789abcdef0123456789abcdef01234567"
+	changed, err := getChangedFilesBetween(repoDir, unknown, "HEAD", true)
+	if err != nil {
+		t.Fatalf("expected fallback without error, got %v", err)
+	}
+	if len(changed) != 1 || changed[0] != "apps/b/two.yaml" {
+		t.Fatalf("expected only the last commit's changes, got %v", changed)
+	}
+
+	if _, err := getChangedFilesBetween(repoDir, unknown, "HEAD", false); err == nil || !strings.Contains(err.Error(), "fetch-depth") {
+		t.Fatalf("expected fetch-depth error for a missing non-forced before commit, got %v", err)
+	}
+}
+
+func TestResolveBaseCommit(t *testing.T) {
+	repoDir := t.TempDir()
+
+	runGit(t, repoDir, "init", "-b", "main")
+	runGit(t, repoDir, "config", "user.email", "test@example.com")
+	runGit(t, repoDir, "config", "user.name", "Test User")
+
+	mustWriteFile(t, filepath.Join(repoDir, "a.txt"), "1")
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-m", "first")
+	first := gitRevParse(t, repoDir, "HEAD")
+
+	runGit(t, repoDir, "checkout", "-b", "feature")
+	mustWriteFile(t, filepath.Join(repoDir, "a.txt"), "2")
+	runGit(t, repoDir, "commit", "-am", "second")
+	mustWriteFile(t, filepath.Join(repoDir, "a.txt"), "3")
+	runGit(t, repoDir, "commit", "-am", "third")
+	second := gitRevParse(t, repoDir, "HEAD~1")
+
+	for _, tc := range []struct {
+		name string
+		cs   changeSet
+		want string
+	}{
+		{"base ref uses merge-base", changeSet{BaseRef: "main"}, first},
+		{"push uses before", changeSet{Before: first, After: "HEAD"}, first},
+		{"forced push with unknown before uses parent", changeSet{Before: strings.Repeat("a", 40), Forced: true}, second},
+		{"default uses parent", changeSet{}, second},
+	} {
+		got, err := resolveBaseCommit(repoDir, tc.cs)
+		if err != nil || got != tc.want {
+			t.Errorf("%s: got %q, %v; want %q", tc.name, got, err, tc.want)
+		}
+	}
+
+	if _, err := resolveBaseCommit(repoDir, changeSet{Before: strings.Repeat("a", 40)}); err == nil {
+		t.Error("expected error for unknown before commit")
+	}
+}
+
 func runGit(t *testing.T, dir string, args ...string) {
 	t.Helper()
 	cmd := exec.Command("git", args...)
@@ -130,3 +290,14 @@ func contains(list []string, v string) bool {
 	}
 	return false
 }
+
+func gitRevParse(t *testing.T, dir, rev string) string {
+	t.Helper()
+	cmd := exec.Command("git", "rev-parse", rev)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git rev-parse %s failed: %v", rev, err)
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+// matchGlob reports whether the slash-separated name matches pattern.
+// Segments follow path.Match; a "**" segment matches zero or more segments.
+func matchGlob(pattern, name string) bool {
+	pattern = strings.Trim(pattern, "/")
+	name = normalizeRepoRelativePath(name)
+	return matchGlobSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchGlobSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Collapse consecutive "**" and try every possible split.
+			for len(pattern) > 0 && pattern[0] == "**" {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchGlobSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		ok, err := path.Match(pattern[0], name[0])
+		if err != nil || !ok {
+			return false
+		}
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+	return len(name) == 0
+}
+
+// firstGlobMatch returns the first name matched by any of patterns.
+func firstGlobMatch(patterns, names []string) (pattern, name string, ok bool) {
+	for _, n := range names {
+		for _, p := range patterns {
+			if matchGlob(p, n) {
+				return p, n, true
+			}
+		}
+	}
+	return "", "", false
+}
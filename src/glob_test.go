@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"components/**", "components/x/kustomization.yaml", true},
+		{"components/**", "components", true},
+		{"components/**", "apps/components/x.yaml", false},
+		{"**/values.yaml", "values.yaml", true},
+		{"**/values.yaml", "helm/lib/values.yaml", true},
+		{".github/workflows/*.yml", ".github/workflows/ci.yml", true},
+		{".github/workflows/*.yml", ".github/workflows/nested/ci.yml", false},
+		{"apps/**/*.md", "apps/foo/bar/README.md", true},
+		{"apps/*/README.md", "apps/foo/bar/README.md", false},
+		{"[", "anything", false},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFirstGlobMatch(t *testing.T) {
+	pattern, name, ok := firstGlobMatch([]string{"docs/**", "components/**"}, []string{"apps/a.yaml", "components/x/cm.yaml"})
+	if !ok || pattern != "components/**" || name != "components/x/cm.yaml" {
+		t.Fatalf("unexpected match: %q %q %v", pattern, name, ok)
+	}
+	if _, _, ok := firstGlobMatch(nil, []string{"apps/a.yaml"}); ok {
+		t.Fatalf("expected no match without patterns")
+	}
+}
@@ -1,3 +1,5 @@
 go 1.25
 
 module github.com/novog93/kustomize-action
+
+require gopkg.in/yaml.v3 v3.0.1
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const kustomizeIgnoreFile = ".kustomizeignore"
+
+// ignoreRule is a single gitignore-style pattern.
+type ignoreRule struct {
+	pattern string
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreRules is an ordered gitignore-style pattern list; the last matching rule wins.
+type ignoreRules []ignoreRule
+
+// parseIgnoreRules parses gitignore syntax: blank lines and "#" comments are
+// skipped, "!" negates, a trailing "/" matches directories only, and patterns
+// without an inner "/" match at any depth.
+func parseIgnoreRules(lines []string) ignoreRules {
+	var rules ignoreRules
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var r ignoreRule
+		if strings.HasPrefix(line, "!") {
+			r.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		if strings.Contains(line, "/") {
+			line = strings.TrimPrefix(line, "/")
+		} else {
+			line = "**/" + line
+		}
+		r.pattern = line
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// match reports whether relPath is ignored. matched is false when no rule applies.
+// As in git, everything below an ignored directory is ignored and cannot be
+// re-included by a later "!" rule.
+func (rules ignoreRules) match(relPath string, isDir bool) (ignored, matched bool) {
+	relPath = normalizeRepoRelativePath(relPath)
+	if relPath == "" {
+		return false, false
+	}
+	for _, dir := range parentDirs(relPath) {
+		if ig, _ := rules.matchSelf(dir, true); ig {
+			return true, true
+		}
+	}
+	return rules.matchSelf(relPath, isDir)
+}
+
+// matchSelf applies rules to relPath itself, ignoring its parent directories.
+func (rules ignoreRules) matchSelf(relPath string, isDir bool) (ignored, matched bool) {
+	for _, r := range rules {
+		if (!r.dirOnly || isDir) && matchGlob(r.pattern, relPath) {
+			ignored = !r.negate
+			matched = true
+		}
+	}
+	return ignored, matched
+}
+
+// parentDirs returns the parent directories of the slash-separated relPath,
+// outermost first.
+func parentDirs(relPath string) []string {
+	var dirs []string
+	for dir := path.Dir(relPath); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		dirs = append([]string{dir}, dirs...)
+	}
+	return dirs
+}
+
+// filterIgnoredFiles drops every file matched by rules.
+func filterIgnoredFiles(files []string, rules ignoreRules) []string {
+	if len(rules) == 0 {
+		return files
+	}
+	out := make([]string, 0, len(files))
+	for _, f := range files {
+		if ignored, _ := rules.match(f, false); ignored {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// kustomizeIgnore collects .kustomizeignore files found while scanning. Like
+// .gitignore, each file applies to the directory containing it and deeper
+// files take precedence over shallower ones.
+type kustomizeIgnore struct {
+	scopes map[string]ignoreRules
+}
+
+func newKustomizeIgnore() *kustomizeIgnore {
+	return &kustomizeIgnore{scopes: map[string]ignoreRules{}}
+}
+
+// load reads the .kustomizeignore in dir, if any; rel is dir relative to the scan root.
+func (k *kustomizeIgnore) load(dir, rel string) {
+	b, err := os.ReadFile(filepath.Join(dir, kustomizeIgnoreFile))
+	if err != nil {
+		return
+	}
+	if rules := parseIgnoreRules(strings.Split(string(b), "\n")); len(rules) > 0 {
+		k.scopes[rel] = rules
+	}
+}
+
+// ignored reports whether rel (relative to the scan root) is ignored. A path
+// inside an ignored directory stays ignored whatever deeper scopes say.
+func (k *kustomizeIgnore) ignored(rel string, isDir bool) bool {
+	rel = normalizeRepoRelativePath(rel)
+	if rel == "" || len(k.scopes) == 0 {
+		return false
+	}
+	for _, dir := range parentDirs(rel) {
+		if k.ignoredSelf(dir, true) {
+			return true
+		}
+	}
+	return k.ignoredSelf(rel, isDir)
+}
+
+// ignoredSelf applies every scope above rel to rel itself.
+func (k *kustomizeIgnore) ignoredSelf(rel string, isDir bool) bool {
+	// Walk scopes from the scan root down to the parent of rel.
+	scopes := []string{""}
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		scopes = append(scopes, strings.Join(parts[:i], "/"))
+	}
+
+	ignored := false
+	for _, scope := range scopes {
+		rules, ok := k.scopes[scope]
+		if !ok {
+			continue
+		}
+		sub := rel
+		if scope != "" {
+			sub = strings.TrimPrefix(rel, scope+"/")
+		}
+		if ig, matched := rules.matchSelf(sub, isDir); matched {
+			ignored = ig
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIgnoreRules_Match(t *testing.T) {
+	rules := parseIgnoreRules([]string{
+		"# docs never matter",
+		"*.md",
+		"!apps/important/CHANGELOG.md",
+		"docs/",
+		"/tmp",
+		"apps/**/tests/*.yaml",
+	})
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"README.md", false, true},
+		{"apps/foo/README.md", false, true},
+		{"apps/important/CHANGELOG.md", false, false},
+		{"apps/foo/docs/guide.yaml", false, true},
+		{"docs", false, false},
+		{"docs", true, true},
+		{"tmp/x.yaml", false, true},
+		{"apps/tmp/x.yaml", false, false},
+		{"apps/foo/bar/tests/case.yaml", false, true},
+		{"apps/foo/deployment.yaml", false, false},
+	}
+	for _, tt := range tests {
+		if got, _ := rules.match(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("match(%q, dir=%v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreRules_MatchCannotReincludeBelowIgnoredDir(t *testing.T) {
+	rules := parseIgnoreRules([]string{"dir/", "!dir/file", "other/*", "!other/keep"})
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"dir/file", true},
+		{"dir/sub/file", true},
+		{"other/drop", true},
+		{"other/keep", false},
+	}
+	for _, tt := range tests {
+		if got, _ := rules.match(tt.path, false); got != tt.want {
+			t.Errorf("match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestKustomizeIgnore_DeeperScopeCannotReincludeBelowIgnoredDir(t *testing.T) {
+	k := newKustomizeIgnore()
+	k.scopes[""] = parseIgnoreRules([]string{"apps/legacy/"})
+	k.scopes["apps/legacy"] = parseIgnoreRules([]string{"!keep"})
+	if !k.ignored("apps/legacy/keep", true) {
+		t.Error("expected apps/legacy/keep to stay ignored")
+	}
+	if k.ignored("apps/web", true) {
+		t.Error("expected apps/web not to be ignored")
+	}
+}
+
+func TestFilterIgnoredFiles(t *testing.T) {
+	rules := parseIgnoreRules([]string{"**/*.md"})
+	got := filterIgnoredFiles([]string{"apps/a/README.md", "apps/a/deploy.yaml", "CONTRIBUTING.md"}, rules)
+	expected := []string{"apps/a/deploy.yaml"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+
+	all := []string{"a.md"}
+	if got := filterIgnoredFiles(all, nil); !reflect.DeepEqual(got, all) {
+		t.Fatalf("expected no filtering without rules, got %v", got)
+	}
+}
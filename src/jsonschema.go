@@ -0,0 +1,392 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxSchemaErrorsPerDocument bounds how many schema errors one object reports.
+const maxSchemaErrorsPerDocument = 10
+
+// schemaError is a single violation found while validating an object.
+type schemaError struct {
+	Path    string
+	Message string
+}
+
+func (e schemaError) String() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// schemaRefResolver loads the document a $ref points at, relative to the
+// file the reference appears in. It returns the document and its file name.
+type schemaRefResolver func(fromFile, file string) (map[string]interface{}, string, error)
+
+// schemaValidation validates decoded YAML values against the subset of JSON
+// Schema (and the OpenAPI v3 extensions) used by Kubernetes and CRD schemas:
+// $ref, type, nullable, enum, properties, required, additionalProperties,
+// items, allOf/anyOf/oneOf/not, numeric, string and array bounds, plus the
+// x-kubernetes-int-or-string and x-kubernetes-preserve-unknown-fields markers.
+type schemaValidation struct {
+	resolve schemaRefResolver
+	errors  []schemaError
+}
+
+// validateAgainstSchema returns the violations of value against schema.
+// file and doc locate the schema so relative $refs can be resolved.
+func validateAgainstSchema(value interface{}, schema map[string]interface{}, file string, doc map[string]interface{}, resolve schemaRefResolver) []schemaError {
+	v := &schemaValidation{resolve: resolve}
+	v.validate(value, schema, schemaScope{file: file, doc: doc}, "")
+	return v.errors
+}
+
+// schemaScope is the schema document currently used to resolve "#/..." refs.
+type schemaScope struct {
+	file string
+	doc  map[string]interface{}
+}
+
+func (v *schemaValidation) fail(path, format string, args ...interface{}) {
+	if len(v.errors) >= maxSchemaErrorsPerDocument {
+		return
+	}
+	v.errors = append(v.errors, schemaError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *schemaValidation) full() bool {
+	return len(v.errors) >= maxSchemaErrorsPerDocument
+}
+
+// matches reports whether value satisfies schema without recording errors.
+func (v *schemaValidation) matches(value interface{}, schema map[string]interface{}, scope schemaScope, path string) bool {
+	sub := &schemaValidation{resolve: v.resolve}
+	sub.validate(value, schema, scope, path)
+	return len(sub.errors) == 0
+}
+
+func (v *schemaValidation) validate(value interface{}, schema map[string]interface{}, scope schemaScope, path string) {
+	if schema == nil || v.full() {
+		return
+	}
+	// nullable applies before $ref and allOf, which usually describe the non-null value.
+	if value == nil && schema["nullable"] == true {
+		return
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		target, refScope, err := v.resolveRef(ref, scope)
+		if err != nil {
+			v.fail(path, "cannot resolve schema reference %s: %v", ref, err)
+			return
+		}
+		v.validate(value, target, refScope, path)
+		return
+	}
+
+	for _, s := range schemaList(schema["allOf"]) {
+		v.validate(value, s, scope, path)
+	}
+	if alts := schemaList(schema["anyOf"]); len(alts) > 0 {
+		ok := false
+		for _, s := range alts {
+			if v.matches(value, s, scope, path) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			v.fail(path, "does not match any allowed schema")
+		}
+	}
+	if alts := schemaList(schema["oneOf"]); len(alts) > 0 {
+		n := 0
+		for _, s := range alts {
+			if v.matches(value, s, scope, path) {
+				n++
+			}
+		}
+		if n != 1 {
+			v.fail(path, "must match exactly one allowed schema, matched %d", n)
+		}
+	}
+	if not, ok := schema["not"].(map[string]interface{}); ok && v.matches(value, not, scope, path) {
+		v.fail(path, "must not match the excluded schema")
+	}
+
+	if value == nil {
+		if schema["nullable"] == true || typeAllowed(schema["type"], "null") || schema["type"] == nil {
+			return
+		}
+		v.fail(path, "expected %s, got null", typeNames(schema["type"]))
+		return
+	}
+
+	intOrString := schema["x-kubernetes-int-or-string"] == true || schema["format"] == "int-or-string"
+	if intOrString {
+		if jsonType(value) != "string" && jsonType(value) != "integer" {
+			v.fail(path, "expected integer or string, got %s", jsonType(value))
+			return
+		}
+	} else if t := schema["type"]; t != nil && !typeAllowed(t, jsonType(value)) && !(jsonType(value) == "integer" && typeAllowed(t, "number")) {
+		v.fail(path, "expected %s, got %s", typeNames(t), jsonType(value))
+		return
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if schemaValuesEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "value %v is not one of %v", value, enum)
+		}
+	}
+
+	switch val := value.(type) {
+	case map[string]interface{}:
+		v.validateObject(val, schema, scope, path)
+	case []interface{}:
+		v.validateArray(val, schema, scope, path)
+	case string:
+		v.validateString(val, schema, path)
+	default:
+		if f, ok := schemaNumber(value); ok {
+			v.validateNumber(f, schema, path)
+		}
+	}
+}
+
+func (v *schemaValidation) validateObject(obj map[string]interface{}, schema map[string]interface{}, scope schemaScope, path string) {
+	for _, r := range stringList(schema["required"]) {
+		if _, ok := obj[r]; !ok {
+			v.fail(path, "missing required field %q", r)
+		}
+	}
+	if n, ok := schemaNumber(schema["minProperties"]); ok && float64(len(obj)) < n {
+		v.fail(path, "must have at least %v properties", n)
+	}
+	if n, ok := schemaNumber(schema["maxProperties"]); ok && float64(len(obj)) > n {
+		v.fail(path, "must have at most %v properties", n)
+	}
+
+	props, _ := schema["properties"].(map[string]interface{})
+	preserveUnknown := schema["x-kubernetes-preserve-unknown-fields"] == true
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		child := joinSchemaPath(path, k)
+		if ps, ok := props[k].(map[string]interface{}); ok {
+			v.validate(obj[k], ps, scope, child)
+			continue
+		}
+		switch ap := schema["additionalProperties"].(type) {
+		case bool:
+			if !ap && !preserveUnknown {
+				v.fail(child, "unknown field %q", k)
+			}
+		case map[string]interface{}:
+			v.validate(obj[k], ap, scope, child)
+		}
+	}
+}
+
+func (v *schemaValidation) validateArray(arr []interface{}, schema map[string]interface{}, scope schemaScope, path string) {
+	if n, ok := schemaNumber(schema["minItems"]); ok && float64(len(arr)) < n {
+		v.fail(path, "must have at least %v items", n)
+	}
+	if n, ok := schemaNumber(schema["maxItems"]); ok && float64(len(arr)) > n {
+		v.fail(path, "must have at most %v items", n)
+	}
+	items, ok := schema["items"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	for i, item := range arr {
+		v.validate(item, items, scope, fmt.Sprintf("%s[%d]", path, i))
+	}
+}
+
+func (v *schemaValidation) validateString(s string, schema map[string]interface{}, path string) {
+	n := float64(len([]rune(s)))
+	if min, ok := schemaNumber(schema["minLength"]); ok && n < min {
+		v.fail(path, "must be at least %v characters", min)
+	}
+	if max, ok := schemaNumber(schema["maxLength"]); ok && n > max {
+		v.fail(path, "must be at most %v characters", max)
+	}
+	if p, ok := schema["pattern"].(string); ok {
+		if re, err := regexp.Compile(p); err == nil && !re.MatchString(s) {
+			v.fail(path, "%q does not match pattern %q", s, p)
+		}
+	}
+}
+
+func (v *schemaValidation) validateNumber(f float64, schema map[string]interface{}, path string) {
+	if min, ok := schemaNumber(schema["minimum"]); ok {
+		if schema["exclusiveMinimum"] == true && f <= min {
+			v.fail(path, "must be greater than %v", min)
+		} else if f < min {
+			v.fail(path, "must be at least %v", min)
+		}
+	}
+	if max, ok := schemaNumber(schema["maximum"]); ok {
+		if schema["exclusiveMaximum"] == true && f >= max {
+			v.fail(path, "must be less than %v", max)
+		} else if f > max {
+			v.fail(path, "must be at most %v", max)
+		}
+	}
+	// JSON Schema draft 6+ spells the exclusive bounds as numbers.
+	if min, ok := schemaNumber(schema["exclusiveMinimum"]); ok && f <= min {
+		v.fail(path, "must be greater than %v", min)
+	}
+	if max, ok := schemaNumber(schema["exclusiveMaximum"]); ok && f >= max {
+		v.fail(path, "must be less than %v", max)
+	}
+}
+
+// resolveRef follows a "#/json/pointer" or "file.json#/json/pointer" reference.
+func (v *schemaValidation) resolveRef(ref string, scope schemaScope) (map[string]interface{}, schemaScope, error) {
+	file, pointer, _ := strings.Cut(ref, "#")
+	if file != "" {
+		if v.resolve == nil {
+			return nil, scope, fmt.Errorf("external references are not supported")
+		}
+		doc, name, err := v.resolve(scope.file, file)
+		if err != nil {
+			return nil, scope, err
+		}
+		scope = schemaScope{file: name, doc: doc}
+	}
+	var cur interface{} = scope.doc
+	for _, tok := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		if tok == "" {
+			continue
+		}
+		tok = strings.NewReplacer("~1", "/", "~0", "~").Replace(tok)
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, scope, fmt.Errorf("pointer %q not found", pointer)
+		}
+		if cur, ok = m[tok]; !ok {
+			return nil, scope, fmt.Errorf("pointer %q not found", pointer)
+		}
+	}
+	target, ok := cur.(map[string]interface{})
+	if !ok {
+		return nil, scope, fmt.Errorf("pointer %q is not a schema", pointer)
+	}
+	return target, scope, nil
+}
+
+// jsonType names the JSON type of a value decoded from YAML.
+func jsonType(value interface{}) string {
+	switch val := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string, time.Time:
+		return "string"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case int, int64, uint64:
+		return "integer"
+	case float64:
+		if val == math.Trunc(val) && !math.IsInf(val, 0) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func typeAllowed(t interface{}, name string) bool {
+	switch tt := t.(type) {
+	case string:
+		return tt == name
+	case []interface{}:
+		for _, x := range tt {
+			if x == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func typeNames(t interface{}) string {
+	switch tt := t.(type) {
+	case string:
+		return tt
+	case []interface{}:
+		return strings.Join(stringList(tt), " or ")
+	}
+	return "a value"
+}
+
+func schemaNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func schemaValuesEqual(a, b interface{}) bool {
+	if fa, ok := schemaNumber(a); ok {
+		fb, ok := schemaNumber(b)
+		return ok && fa == fb
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b) && jsonType(a) == jsonType(b)
+}
+
+func schemaList(v interface{}) []map[string]interface{} {
+	list, _ := v.([]interface{})
+	var out []map[string]interface{}
+	for _, s := range list {
+		if m, ok := s.(map[string]interface{}); ok {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func stringList(v interface{}) []string {
+	list, _ := v.([]interface{})
+	var out []string
+	for _, s := range list {
+		if str, ok := s.(string); ok {
+			out = append(out, str)
+		}
+	}
+	return out
+}
+
+func joinSchemaPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func decodeYAML(t *testing.T, s string) map[string]interface{} {
+	t.Helper()
+	var m map[string]interface{}
+	if err := yaml.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatalf("decode yaml: %v", err)
+	}
+	return m
+}
+
+func TestValidateAgainstSchema(t *testing.T) {
+	schema := decodeYAML(t, `
+type: object
+required: [spec]
+properties:
+  spec:
+    type: object
+    additionalProperties: false
+    properties:
+      replicas: {type: integer, minimum: 0}
+      mode: {type: string, enum: [fast, slow]}
+      port: {x-kubernetes-int-or-string: true}
+      name: {type: string, pattern: "^[a-z]+$", maxLength: 5}
+      ratio: {type: number}
+      labels:
+        type: object
+        additionalProperties: {type: string}
+      items:
+        type: array
+        maxItems: 2
+        items: {type: string}
+      extra:
+        type: object
+        x-kubernetes-preserve-unknown-fields: true
+      optional: {type: string, nullable: true}
+`)
+
+	cases := []struct {
+		name string
+		doc  string
+		want []string
+	}{
+		{"valid", "spec: {replicas: 2, mode: fast, port: http, name: web, ratio: 1, labels: {a: b}, items: [x], extra: {any: 1}, optional: null}", nil},
+		{"int-or-string accepts int", "spec: {port: 8080}", nil},
+		{"missing required", "{}", []string{`missing required field "spec"`}},
+		{"wrong type", "spec: {replicas: two}", []string{"spec.replicas: expected integer, got string"}},
+		{"minimum", "spec: {replicas: -1}", []string{"spec.replicas: must be at least 0"}},
+		{"enum", "spec: {mode: medium}", []string{"spec.mode: value medium is not one of [fast slow]"}},
+		{"unknown field", "spec: {replica: 1}", []string{`spec.replica: unknown field "replica"`}},
+		{"additionalProperties schema", "spec: {labels: {a: 1}}", []string{"spec.labels.a: expected string, got integer"}},
+		{"array", "spec: {items: [a, 1, c]}", []string{"spec.items: must have at most 2 items", "spec.items[1]: expected string, got integer"}},
+		{"pattern and length", "spec: {name: Webserver}", []string{"spec.name: must be at most 5 characters", `spec.name: "Webserver" does not match pattern "^[a-z]+$"`}},
+		{"int-or-string rejects bool", "spec: {port: true}", []string{"spec.port: expected integer or string, got boolean"}},
+		{"null not allowed", "spec: {mode: null}", []string{"spec.mode: expected string, got null"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, e := range validateAgainstSchema(decodeYAML(t, tc.doc), schema, "", schema, nil) {
+				got = append(got, e.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("got errors:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tc.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestValidateAgainstSchema_RefsAndCombinators(t *testing.T) {
+	schema := decodeYAML(t, `
+definitions:
+  port:
+    oneOf: [{type: string}, {type: integer}]
+type: object
+properties:
+  port: {$ref: "#/definitions/port"}
+  any:
+    anyOf: [{type: boolean}, {type: array}]
+  ext: {$ref: "other.json#/definitions/x"}
+`)
+	resolve := func(fromFile, file string) (map[string]interface{}, string, error) {
+		return map[string]interface{}{"definitions": map[string]interface{}{"x": map[string]interface{}{"type": "string"}}}, file, nil
+	}
+
+	errs := validateAgainstSchema(decodeYAML(t, "{port: 80, any: true, ext: ok}"), schema, "", schema, resolve)
+	if len(errs) != 0 {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+
+	errs = validateAgainstSchema(decodeYAML(t, "{port: 1.5, any: x, ext: 1}"), schema, "", schema, resolve)
+	var got []string
+	for _, e := range errs {
+		got = append(got, e.String())
+	}
+	want := []string{
+		"any: does not match any allowed schema",
+		"ext: expected string, got integer",
+		"port: must match exactly one allowed schema, matched 0",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got errors:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestValidateAgainstSchema_CapsErrors(t *testing.T) {
+	schema := decodeYAML(t, "type: object\nadditionalProperties: false\n")
+	doc := map[string]interface{}{}
+	for i := 0; i < maxSchemaErrorsPerDocument+5; i++ {
+		doc[strings.Repeat("k", i+1)] = i
+	}
+	if errs := validateAgainstSchema(doc, schema, "", schema, nil); len(errs) != maxSchemaErrorsPerDocument {
+		t.Errorf("expected %d errors, got %d", maxSchemaErrorsPerDocument, len(errs))
+	}
+}
+
+func TestValidateAgainstSchema_Keywords(t *testing.T) {
+	cases := []struct {
+		keyword string
+		schema  string
+		value   string
+		want    []string
+	}{
+		{"type", "{type: string}", "v: 1", []string{"v: expected string, got integer"}},
+		{"type list", "{type: [string, integer]}", "v: 1", nil},
+		{"type number accepts integer", "{type: number}", "v: 1", nil},
+		{"type number", "{type: integer}", "v: 1.5", []string{"v: expected integer, got number"}},
+		{"nullable", "{type: string, nullable: true}", "v: null", nil},
+		{"nullable with $ref", "{$ref: '#/definitions/obj', nullable: true}", "v: null", nil},
+		{"nullable with allOf $ref", "{allOf: [{$ref: '#/definitions/obj'}], nullable: true}", "v: null", nil},
+		{"null through $ref", "{$ref: '#/definitions/obj'}", "v: null", []string{"v: expected object, got null"}},
+		{"enum", "{enum: [1, two]}", "v: 2", []string{"v: value 2 is not one of [1 two]"}},
+		{"enum string does not match number", "{enum: ['1']}", "v: 1", []string{"v: value 1 is not one of [1]"}},
+		{"required", "{type: object, required: [a, b]}", "v: {a: 1}", []string{`v: missing required field "b"`}},
+		{"properties", "{properties: {a: {type: string}}}", "v: {a: 1, b: 2}", []string{"v.a: expected string, got integer"}},
+		{"additionalProperties false", "{properties: {a: {}}, additionalProperties: false}", "v: {a: 1, b: 2}", []string{`v.b: unknown field "b"`}},
+		{"additionalProperties schema", "{properties: {a: {}}, additionalProperties: {type: integer}}", "v: {a: x, b: 2, c: y}", []string{"v.c: expected integer, got string"}},
+		{"additionalProperties with preserve-unknown-fields", "{additionalProperties: false, x-kubernetes-preserve-unknown-fields: true}", "v: {b: 2}", nil},
+		{"minProperties", "{minProperties: 2}", "v: {a: 1}", []string{"v: must have at least 2 properties"}},
+		{"maxProperties", "{maxProperties: 1}", "v: {a: 1, b: 2}", []string{"v: must have at most 1 properties"}},
+		{"items", "{items: {type: integer}}", "v: [1, x]", []string{"v[1]: expected integer, got string"}},
+		{"minItems", "{minItems: 1}", "v: []", []string{"v: must have at least 1 items"}},
+		{"maxItems", "{maxItems: 1}", "v: [1, 2]", []string{"v: must have at most 1 items"}},
+		{"minLength counts runes", "{minLength: 3}", "v: äö", []string{"v: must be at least 3 characters"}},
+		{"maxLength", "{maxLength: 1}", "v: ab", []string{"v: must be at most 1 characters"}},
+		{"pattern", "{pattern: '^a'}", "v: ba", []string{`v: "ba" does not match pattern "^a"`}},
+		{"minimum", "{minimum: 1}", "v: 0", []string{"v: must be at least 1"}},
+		{"maximum", "{maximum: 1}", "v: 2", []string{"v: must be at most 1"}},
+		{"exclusiveMinimum bool", "{minimum: 1, exclusiveMinimum: true}", "v: 1", []string{"v: must be greater than 1"}},
+		{"exclusiveMaximum bool", "{maximum: 1, exclusiveMaximum: true}", "v: 1", []string{"v: must be less than 1"}},
+		{"exclusiveMinimum number", "{exclusiveMinimum: 1}", "v: 1", []string{"v: must be greater than 1"}},
+		{"exclusiveMaximum number", "{exclusiveMaximum: 1}", "v: 0.5", nil},
+		{"allOf", "{allOf: [{type: integer}, {minimum: 5}]}", "v: 3", []string{"v: must be at least 5"}},
+		{"anyOf", "{anyOf: [{type: integer}, {type: boolean}]}", "v: x", []string{"v: does not match any allowed schema"}},
+		{"oneOf single match", "{oneOf: [{type: integer}, {type: string}]}", "v: x", nil},
+		{"oneOf multiple matches", "{oneOf: [{type: integer}, {minimum: 0}]}", "v: 1", []string{"v: must match exactly one allowed schema, matched 2"}},
+		{"not", "{not: {type: string}}", "v: x", []string{"v: must not match the excluded schema"}},
+		{"$ref escaped pointer", "{$ref: '#/definitions/a~1b'}", "v: 1", []string{"v: expected string, got integer"}},
+		{"$ref unresolvable", "{$ref: '#/definitions/missing'}", "v: 1", []string{`v: cannot resolve schema reference #/definitions/missing: pointer "/definitions/missing" not found`}},
+		{"int-or-string format", "{type: string, format: int-or-string}", "v: 1", nil},
+		{"int-or-string rejects number", "{x-kubernetes-int-or-string: true}", "v: 1.5", []string{"v: expected integer or string, got number"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.keyword, func(t *testing.T) {
+			root := decodeYAML(t, "definitions: {obj: {type: object}, a/b: {type: string}}\ntype: object\nproperties: {v: "+tc.schema+"}\n")
+			var got []string
+			for _, e := range validateAgainstSchema(decodeYAML(t, tc.value), root, "", root, nil) {
+				got = append(got, e.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("got errors:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tc.want, "\n"))
+			}
+		})
+	}
+}
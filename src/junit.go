@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// junitTestSuites is the root element of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// renderJUnitReport converts the per-root results into a JUnit XML document
+// with one testcase per root. Failures carry the kustomize stderr; canceled
+// and timed-out roots are reported as skipped.
+func renderJUnitReport(summary Summary) ([]byte, error) {
+	suite := junitTestSuite{Name: "kustomize build"}
+	total := 0.0
+	for _, r := range summary.Results {
+		tc := junitTestCase{
+			Name:      displayRoot(r.Root),
+			ClassName: "kustomize",
+			Time:      junitSeconds(r.DurationSeconds),
+		}
+		switch r.Status {
+		case statusFailed:
+			tc.Failure = &junitFailure{Message: annotationMessage(r), Type: r.Status, Text: r.Stderr}
+			suite.Failures++
+		case statusCanceled:
+			tc.Skipped = &junitSkipped{Message: "build canceled"}
+			suite.Skipped++
+		case statusTimedOut:
+			tc.Skipped = &junitSkipped{Message: "build timed out"}
+			suite.Skipped++
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+		total += r.DurationSeconds
+	}
+	suite.Tests = len(suite.TestCases)
+	suite.Time = junitSeconds(total)
+
+	report := junitTestSuites{
+		Name:     suite.Name,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+	b, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(b, '\n')...), nil
+}
+
+// writeJUnitReport writes the JUnit report to name inside outputDir.
+func writeJUnitReport(outputDir, name string, summary Summary) (string, error) {
+	b, err := renderJUnitReport(summary)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(outputDir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, b, 0o644)
+}
+
+func junitSeconds(s float64) string {
+	return fmt.Sprintf("%.3f", s)
+}
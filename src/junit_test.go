@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderJUnitReport(t *testing.T) {
+	summary := Summary{Results: []RootResult{
+		{Root: "apps/web", Status: statusSuccess, DurationSeconds: 1.5},
+		{Root: "apps/bad", Status: statusFailed, DurationSeconds: 0.25, Stderr: "Error: accumulating resources <oops>\n"},
+		{Root: "apps/slow", Status: statusTimedOut, DurationSeconds: 30},
+		{Root: "apps/late", Status: statusCanceled},
+	}}
+
+	b, err := renderJUnitReport(summary)
+	if err != nil {
+		t.Fatalf("renderJUnitReport: %v", err)
+	}
+
+	var got junitTestSuites
+	if err := xml.Unmarshal(b, &got); err != nil {
+		t.Fatalf("report is not valid XML: %v\n%s", err, b)
+	}
+	if got.Tests != 4 || got.Failures != 1 || got.Skipped != 2 {
+		t.Errorf("unexpected totals: tests=%d failures=%d skipped=%d", got.Tests, got.Failures, got.Skipped)
+	}
+	if got.Time != "31.750" {
+		t.Errorf("expected total time 31.750, got %s", got.Time)
+	}
+
+	cases := got.Suites[0].TestCases
+	if cases[0].Name != "apps/web" || cases[0].Time != "1.500" || cases[0].Failure != nil || cases[0].Skipped != nil {
+		t.Errorf("unexpected success testcase: %+v", cases[0])
+	}
+	if cases[1].Failure == nil || !strings.Contains(cases[1].Failure.Text, "<oops>") {
+		t.Errorf("expected failure with stderr, got %+v", cases[1])
+	}
+	if cases[2].Skipped == nil || cases[2].Skipped.Message != "build timed out" {
+		t.Errorf("expected timed-out root skipped, got %+v", cases[2])
+	}
+	if cases[3].Skipped == nil || cases[3].Skipped.Message != "build canceled" {
+		t.Errorf("expected canceled root skipped, got %+v", cases[3])
+	}
+}
+
+func TestWriteJUnitReport(t *testing.T) {
+	dir := t.TempDir()
+
+	path, err := writeJUnitReport(dir, "junit.xml", Summary{Results: []RootResult{{Root: "", Status: statusSuccess}}})
+	if err != nil {
+		t.Fatalf("writeJUnitReport: %v", err)
+	}
+	if path != filepath.Join(dir, "junit.xml") {
+		t.Errorf("unexpected path %s", path)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(b), "<?xml") || !strings.Contains(string(b), `<testcase name="."`) {
+		t.Errorf("unexpected report:\n%s", b)
+	}
+}
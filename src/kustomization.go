@@ -0,0 +1,304 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// kustomizationFileNames lists the file names kustomize accepts, in its lookup order.
+var kustomizationFileNames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+func isKustomizationFileName(name string) bool {
+	for _, n := range kustomizationFileNames {
+		if name == n {
+			return true
+		}
+	}
+	return false
+}
+
+// kustomizationFileIn returns the kustomization file inside dir, or "" if there is none.
+func kustomizationFileIn(dir string) string {
+	for _, name := range kustomizationFileNames {
+		p := filepath.Join(dir, name)
+		if fileExists(p) {
+			return p
+		}
+	}
+	return ""
+}
+
+// rootKustomizationFile returns the slash-separated path of the kustomization
+// file of root, assuming kustomization.yaml when none exists.
+func rootKustomizationFile(root string) string {
+	dir := root
+	if dir == "" {
+		dir = "."
+	}
+	if kfile := kustomizationFileIn(dir); kfile != "" {
+		return filepath.ToSlash(filepath.Clean(kfile))
+	}
+	return joinRepoPath(filepath.ToSlash(dir), "kustomization.yaml")
+}
+
+// kustomization is the subset of a kustomization file that references local paths.
+type kustomization struct {
+	Kind                  string   `yaml:"kind"`
+	Resources             []string `yaml:"resources"`
+	Bases                 []string `yaml:"bases"`
+	Components            []string `yaml:"components"`
+	Crds                  []string `yaml:"crds"`
+	Configurations        []string `yaml:"configurations"`
+	Generators            []string `yaml:"generators"`
+	Transformers          []string `yaml:"transformers"`
+	Validators            []string `yaml:"validators"`
+	PatchesStrategicMerge []string `yaml:"patchesStrategicMerge"`
+	Patches               []struct {
+		Path string `yaml:"path"`
+	} `yaml:"patches"`
+	PatchesJSON6902 []struct {
+		Path string `yaml:"path"`
+	} `yaml:"patchesJson6902"`
+	Replacements []struct {
+		Path string `yaml:"path"`
+	} `yaml:"replacements"`
+	ConfigMapGenerator []kustomizationGenerator `yaml:"configMapGenerator"`
+	SecretGenerator    []kustomizationGenerator `yaml:"secretGenerator"`
+	HelmCharts         []struct {
+		ValuesFile            string   `yaml:"valuesFile"`
+		AdditionalValuesFiles []string `yaml:"additionalValuesFiles"`
+	} `yaml:"helmCharts"`
+	HelmGlobals struct {
+		ChartHome string `yaml:"chartHome"`
+	} `yaml:"helmGlobals"`
+	OpenAPI struct {
+		Path string `yaml:"path"`
+	} `yaml:"openapi"`
+}
+
+type kustomizationGenerator struct {
+	Files []string `yaml:"files"`
+	Envs  []string `yaml:"envs"`
+	Env   string   `yaml:"env"`
+}
+
+func readKustomization(file string) (*kustomization, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var k kustomization
+	if err := yaml.Unmarshal(b, &k); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// isComponent reports whether k declares kind: Component. Components can only
+// be consumed by other kustomizations and cannot be built standalone.
+func (k *kustomization) isComponent() bool {
+	return k.Kind == "Component"
+}
+
+// splitComponentFiles separates kustomization files declaring kind: Component
+// from buildable ones. Unparseable files are kept as buildable so their
+// errors surface during the build.
+func splitComponentFiles(files []string) (kustomizations, components []string) {
+	for _, f := range files {
+		if k, err := readKustomization(f); err == nil && k.isComponent() {
+			components = append(components, f)
+			continue
+		}
+		kustomizations = append(kustomizations, f)
+	}
+	return kustomizations, components
+}
+
+// localReferences returns every local path referenced by k, relative to its directory.
+// Remote resources and inline patches are skipped.
+func (k *kustomization) localReferences() []string {
+	var refs []string
+	add := func(items ...string) {
+		for _, it := range items {
+			it = strings.TrimSpace(it)
+			if it == "" || strings.Contains(it, "\n") || isRemoteReference(it) {
+				continue
+			}
+			refs = append(refs, it)
+		}
+	}
+
+	add(k.Resources...)
+	add(k.Bases...)
+	add(k.Components...)
+	add(k.Crds...)
+	add(k.Configurations...)
+	add(k.Generators...)
+	add(k.Transformers...)
+	add(k.Validators...)
+	add(k.PatchesStrategicMerge...)
+	for _, p := range k.Patches {
+		add(p.Path)
+	}
+	for _, p := range k.PatchesJSON6902 {
+		add(p.Path)
+	}
+	for _, r := range k.Replacements {
+		add(r.Path)
+	}
+	for _, g := range append(append([]kustomizationGenerator{}, k.ConfigMapGenerator...), k.SecretGenerator...) {
+		for _, f := range g.Files {
+			// Files may be given as "key=path".
+			if i := strings.Index(f, "="); i >= 0 {
+				f = f[i+1:]
+			}
+			add(f)
+		}
+		add(g.Envs...)
+		add(g.Env)
+	}
+	for _, h := range k.HelmCharts {
+		add(h.ValuesFile)
+		add(h.AdditionalValuesFiles...)
+	}
+	if len(k.HelmCharts) > 0 {
+		chartHome := k.HelmGlobals.ChartHome
+		if chartHome == "" {
+			chartHome = "charts"
+		}
+		add(chartHome)
+	}
+	add(k.OpenAPI.Path)
+	return refs
+}
+
+func isRemoteReference(ref string) bool {
+	if strings.Contains(ref, "://") || strings.HasPrefix(ref, "git@") || strings.Contains(ref, "?ref=") {
+		return true
+	}
+	for _, host := range []string{"github.com/", "gitlab.com/", "bitbucket.org/"} {
+		if strings.HasPrefix(ref, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// dependencyGraph is a reverse index from local inputs to the roots whose
+// build transitively reads them. Paths are slash-separated and relative to
+// the directory the graph was built from.
+type dependencyGraph struct {
+	files map[string][]string
+	dirs  map[string][]string
+}
+
+// buildDependencyGraph parses the kustomization of every root and follows
+// local references recursively through nested kustomizations.
+func buildDependencyGraph(baseDir string, roots []string) *dependencyGraph {
+	g := &dependencyGraph{
+		files: map[string][]string{},
+		dirs:  map[string][]string{},
+	}
+	cache := map[string][]string{}
+	for _, r := range roots {
+		root := normalizeRepoRelativeDir(r)
+		files := map[string]bool{}
+		dirs := map[string]bool{}
+		collectKustomizationInputs(baseDir, root, cache, map[string]bool{}, files, dirs)
+		for f := range files {
+			g.files[f] = append(g.files[f], root)
+		}
+		for d := range dirs {
+			g.dirs[d] = append(g.dirs[d], root)
+		}
+	}
+	return g
+}
+
+// findOrphanKustomizations returns the directories in dirs whose kustomization
+// is neither one of roots nor transitively referenced by one, i.e. nothing
+// builds it. The result is never nil so it marshals as a JSON array.
+func findOrphanKustomizations(baseDir string, roots, dirs []string) []string {
+	cache := map[string][]string{}
+	visited := map[string]bool{}
+	for _, r := range roots {
+		collectKustomizationInputs(baseDir, normalizeRepoRelativeDir(r), cache, visited, map[string]bool{}, map[string]bool{})
+	}
+
+	orphans := []string{}
+	for _, d := range dirs {
+		if !visited[normalizeRepoRelativeDir(d)] {
+			orphans = append(orphans, d)
+		}
+	}
+	return orphans
+}
+
+// collectKustomizationInputs records the kustomization file of dir and every
+// local path it references, descending into referenced kustomization directories.
+func collectKustomizationInputs(baseDir, dir string, cache map[string][]string, visited, files, dirs map[string]bool) {
+	if visited[dir] {
+		return
+	}
+	visited[dir] = true
+
+	kfile := kustomizationFileIn(filepath.Join(baseDir, filepath.FromSlash(dir)))
+	if kfile == "" {
+		return
+	}
+	files[joinRepoPath(dir, filepath.Base(kfile))] = true
+
+	refs, ok := cache[dir]
+	if !ok {
+		k, err := readKustomization(kfile)
+		if err != nil {
+			log.Printf("⚠️ Could not parse %s for dependency tracking: %v", kfile, err)
+		} else {
+			for _, ref := range k.localReferences() {
+				p := joinRepoPath(dir, ref)
+				if p == ".." || strings.HasPrefix(p, "../") {
+					continue
+				}
+				refs = append(refs, p)
+			}
+		}
+		cache[dir] = refs
+	}
+
+	for _, p := range refs {
+		info, err := os.Stat(filepath.Join(baseDir, filepath.FromSlash(p)))
+		switch {
+		case err == nil && info.IsDir():
+			if kustomizationFileIn(filepath.Join(baseDir, filepath.FromSlash(p))) != "" {
+				collectKustomizationInputs(baseDir, p, cache, visited, files, dirs)
+			} else {
+				dirs[p] = true
+			}
+		default:
+			// Plain files, and paths that no longer exist (e.g. deleted in this change).
+			files[p] = true
+		}
+	}
+}
+
+// rootsForFile returns the roots that read file, either directly or through a referenced directory.
+func (g *dependencyGraph) rootsForFile(file string) []string {
+	file = normalizeRepoRelativePath(file)
+	out := append([]string{}, g.files[file]...)
+	for dir := path.Dir(file); ; dir = path.Dir(dir) {
+		out = append(out, g.dirs[dir]...)
+		if dir == "." || dir == "/" {
+			break
+		}
+	}
+	return out
+}
+
+func joinRepoPath(dir, ref string) string {
+	return path.Clean(path.Join(dir, filepath.ToSlash(ref)))
+}
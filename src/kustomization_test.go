@@ -0,0 +1,150 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestKustomizationLocalReferences(t *testing.T) {
+	dir := t.TempDir()
+	mustWriteFile(t, filepath.Join(dir, "kustomization.yaml"), `
+resources:
+- deployment.yaml
+- ../../bases/app
+- https://github.com/org/repo//deploy?ref=v1
+- github.com/org/repo/deploy
+components:
+- ../../components/monitoring
+patches:
+- path: patch.yaml
+- patch: |-
+    - op: add
+      path: /x
+patchesStrategicMerge:
+- sm.yaml
+configMapGenerator:
+- name: cfg
+  files:
+  - app.properties
+  - key=other.properties
+  envs:
+  - vars.env
+helmCharts:
+- name: chart
+  valuesFile: values.yaml
+  additionalValuesFiles:
+  - values-prod.yaml
+`)
+
+	k, err := readKustomization(filepath.Join(dir, "kustomization.yaml"))
+	if err != nil {
+		t.Fatalf("readKustomization: %v", err)
+	}
+	got := k.localReferences()
+	sort.Strings(got)
+	expected := []string{
+		"../../bases/app",
+		"../../components/monitoring",
+		"app.properties",
+		"charts",
+		"deployment.yaml",
+		"other.properties",
+		"patch.yaml",
+		"sm.yaml",
+		"values-prod.yaml",
+		"values.yaml",
+		"vars.env",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestBuildDependencyGraph_FollowsNestedKustomizations(t *testing.T) {
+	dir := t.TempDir()
+	mustWriteFile(t, filepath.Join(dir, "components/x/kustomization.yaml"), "kind: Component\nresources:\n- cm.yaml\n")
+	mustWriteFile(t, filepath.Join(dir, "components/x/cm.yaml"), "kind: ConfigMap\n")
+	mustWriteFile(t, filepath.Join(dir, "bases/app/kustomization.yaml"), "resources:\n- deploy.yaml\n")
+	mustWriteFile(t, filepath.Join(dir, "bases/app/deploy.yaml"), "kind: Deployment\n")
+	mustWriteFile(t, filepath.Join(dir, "bases/app/README.md"), "docs")
+	mustWriteFile(t, filepath.Join(dir, "overlays/prod/kustomization.yaml"), "resources:\n- ../../bases/app\ncomponents:\n- ../../components/x\n")
+	mustWriteFile(t, filepath.Join(dir, "overlays/dev/kustomization.yaml"), "resources:\n- ../../bases/app\n")
+
+	g := buildDependencyGraph(dir, []string{"overlays/dev", "overlays/prod"})
+
+	tests := []struct {
+		file     string
+		expected []string
+	}{
+		{file: "bases/app/deploy.yaml", expected: []string{"overlays/dev", "overlays/prod"}},
+		{file: "bases/app/kustomization.yaml", expected: []string{"overlays/dev", "overlays/prod"}},
+		{file: "components/x/cm.yaml", expected: []string{"overlays/prod"}},
+		{file: "bases/app/README.md", expected: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got := g.rootsForFile(tt.file)
+			sort.Strings(got)
+			if len(got) == 0 && len(tt.expected) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestBuildDependencyGraph_HandlesCyclesAndDirectoryInputs(t *testing.T) {
+	dir := t.TempDir()
+	mustWriteFile(t, filepath.Join(dir, "a/kustomization.yaml"), "resources:\n- ../b\n")
+	mustWriteFile(t, filepath.Join(dir, "b/kustomization.yaml"), "resources:\n- ../a\nhelmCharts:\n- name: c\n")
+	mustWriteFile(t, filepath.Join(dir, "b/charts/c/Chart.yaml"), "name: c\n")
+
+	g := buildDependencyGraph(dir, []string{"a"})
+	if got := g.rootsForFile("b/charts/c/Chart.yaml"); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Fatalf("expected chart home to be tracked as a directory input, got %v", got)
+	}
+}
+
+func TestSplitComponentFiles(t *testing.T) {
+	dir := t.TempDir()
+	overlay := filepath.Join(dir, "overlay/kustomization.yaml")
+	component := filepath.Join(dir, "components/x/kustomization.yaml")
+	legacy := filepath.Join(dir, "legacy/Kustomization")
+	broken := filepath.Join(dir, "broken/kustomization.yaml")
+	mustWriteFile(t, overlay, "apiVersion: kustomize.config.k8s.io/v1beta1\nkind: Kustomization\n")
+	mustWriteFile(t, component, "apiVersion: kustomize.config.k8s.io/v1alpha1\nkind: Component\n")
+	mustWriteFile(t, legacy, "resources: []\n")
+	mustWriteFile(t, broken, "resources: [\n")
+
+	kustomizations, components := splitComponentFiles([]string{broken, component, legacy, overlay})
+	if !reflect.DeepEqual(kustomizations, []string{broken, legacy, overlay}) {
+		t.Fatalf("unexpected kustomizations: %v", kustomizations)
+	}
+	if !reflect.DeepEqual(components, []string{component}) {
+		t.Fatalf("unexpected components: %v", components)
+	}
+}
+
+func TestFindOrphanKustomizations(t *testing.T) {
+	dir := t.TempDir()
+	mustWriteFile(t, filepath.Join(dir, "apps/kustomization.yaml"), "resources:\n- a\n")
+	mustWriteFile(t, filepath.Join(dir, "apps/a/kustomization.yaml"), "components:\n- ../../components/x\n")
+	mustWriteFile(t, filepath.Join(dir, "apps/b/kustomization.yaml"), "resources: []\n")
+	mustWriteFile(t, filepath.Join(dir, "components/x/kustomization.yaml"), "kind: Component\n")
+	mustWriteFile(t, filepath.Join(dir, "components/unused/kustomization.yaml"), "kind: Component\n")
+
+	dirs := []string{"apps", "apps/a", "apps/b", "components/unused", "components/x"}
+	got := findOrphanKustomizations(dir, []string{"apps"}, dirs)
+	expected := []string{"apps/b", "components/unused"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+
+	if got := findOrphanKustomizations(dir, dirs, dirs); got == nil || len(got) != 0 {
+		t.Fatalf("expected empty non-nil slice when every dir is a root, got %#v", got)
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContextLines is the number of unchanged lines shown around each change.
+const diffContextLines = 3
+
+// diffLine is one line of an edit script: ' ' kept, '-' removed, '+' added.
+type diffLine struct {
+	Op   byte
+	Text string
+}
+
+// diffMaxEdits bounds the edit distance diffLines searches for. Inputs that
+// differ by more lines are shown as one replace hunk, which keeps time and
+// memory bounded for large rewritten objects.
+const diffMaxEdits = 1000
+
+// diffLines computes a shortest edit script turning a into b (Myers'
+// algorithm), after stripping their common prefix and suffix. When more than
+// diffMaxEdits edits are needed, all of a is removed and all of b added.
+func diffLines(a, b []string) []diffLine {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	var out []diffLine
+	for _, l := range a[:pre] {
+		out = append(out, diffLine{' ', l})
+	}
+	midA, midB := a[pre:len(a)-suf], b[pre:len(b)-suf]
+	mid := myersDiff(midA, midB, diffMaxEdits)
+	if mid == nil {
+		mid = replaceLines(midA, midB)
+	}
+	out = append(out, mid...)
+	for _, l := range a[len(a)-suf:] {
+		out = append(out, diffLine{' ', l})
+	}
+	return out
+}
+
+// replaceLines is the edit script removing every line of a and adding every line of b.
+func replaceLines(a, b []string) []diffLine {
+	out := make([]diffLine, 0, len(a)+len(b))
+	for _, l := range a {
+		out = append(out, diffLine{'-', l})
+	}
+	for _, l := range b {
+		out = append(out, diffLine{'+', l})
+	}
+	return out
+}
+
+// myersDiff returns a shortest edit script turning a into b, or nil when it
+// needs more than maxEdits edits. Only the diagonals reachable at each step
+// are kept for backtracking, so memory is O(D²) rather than O((n+m)·D).
+func myersDiff(a, b []string, maxEdits int) []diffLine {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceLines(a, b)
+	}
+	max := n + m
+	if max > maxEdits {
+		max = maxEdits
+	}
+	off := max + 1
+	v := make([]int, 2*max+3)
+	// trace[d] holds v[-d..d] as it was before step d.
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v[off-d:off+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				return backtrackDiff(a, b, trace)
+			}
+		}
+	}
+	return nil
+}
+
+func backtrackDiff(a, b []string, trace [][]int) []diffLine {
+	var out []diffLine
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := 0
+		if d > 0 {
+			prevX = at(prevK)
+		}
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			out = append(out, diffLine{' ', a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				out = append(out, diffLine{'+', b[y-1]})
+			} else {
+				out = append(out, diffLine{'-', a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out
+}
+
+// unifiedDiff renders the differences between from and to in unified diff
+// format with the given file labels, or "" if they are equal.
+func unifiedDiff(fromName, toName, from, to string) string {
+	var lines []diffLine
+	if from == "" || to == "" {
+		// Added and removed objects need no edit script search.
+		lines = replaceLines(splitLines(from), splitLines(to))
+	} else {
+		lines = diffLines(splitLines(from), splitLines(to))
+	}
+
+	var changes []int
+	for i, l := range lines {
+		if l.Op != ' ' {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+	for i := 0; i < len(changes); {
+		// Merge changes separated by at most two contexts worth of unchanged lines.
+		j := i
+		for j+1 < len(changes) && changes[j+1]-changes[j] <= 2*diffContextLines {
+			j++
+		}
+		start := changes[i] - diffContextLines
+		if start < 0 {
+			start = 0
+		}
+		end := changes[j] + diffContextLines + 1
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		aStart, bStart := 1, 1
+		for _, l := range lines[:start] {
+			if l.Op != '+' {
+				aStart++
+			}
+			if l.Op != '-' {
+				bStart++
+			}
+		}
+		aLen, bLen := 0, 0
+		for _, l := range lines[start:end] {
+			if l.Op != '+' {
+				aLen++
+			}
+			if l.Op != '-' {
+				bLen++
+			}
+		}
+		// An empty range points at the line before it.
+		if aLen == 0 {
+			aStart--
+		}
+		if bLen == 0 {
+			bStart--
+		}
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+		for _, l := range lines[start:end] {
+			b.WriteByte(l.Op)
+			b.WriteString(l.Text)
+			b.WriteByte('\n')
+		}
+		i = j + 1
+	}
+	return b.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
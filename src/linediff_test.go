@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	from := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	to := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n"
+
+	got := unifiedDiff("a/x", "b/x", from, to)
+	want := `--- a/x
++++ b/x
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -11,3 +11,4 @@
+ k
+ l
+ m
++n
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiff_EqualAndAdded(t *testing.T) {
+	if got := unifiedDiff("a", "b", "x\ny\n", "x\ny\n"); got != "" {
+		t.Errorf("expected empty diff for equal input, got:\n%s", got)
+	}
+
+	got := unifiedDiff("/dev/null", "b/x", "", "x\ny\n")
+	want := "--- /dev/null\n+++ b/x\n@@ -0,0 +1,2 @@\n+x\n+y\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDiffLines_RoundTrips(t *testing.T) {
+	a := strings.Split("the quick brown fox jumps over the lazy dog", " ")
+	b := strings.Split("a quick brown cat jumps over the dog today", " ")
+
+	var from, to []string
+	changes := 0
+	for _, l := range diffLines(a, b) {
+		if l.Op != '+' {
+			from = append(from, l.Text)
+		}
+		if l.Op != '-' {
+			to = append(to, l.Text)
+		}
+		if l.Op != ' ' {
+			changes++
+		}
+	}
+	if fmt.Sprint(from) != fmt.Sprint(a) || fmt.Sprint(to) != fmt.Sprint(b) {
+		t.Fatalf("edit script does not reproduce inputs: %v / %v", from, to)
+	}
+	if changes != 6 {
+		t.Errorf("expected a shortest script of 6 edits, got %d", changes)
+	}
+}
+
+func TestUnifiedDiff_LargeAddedObject(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	got := unifiedDiff("/dev/null", "b/crd", "", b.String())
+	if !strings.HasPrefix(got, "--- /dev/null\n+++ b/crd\n@@ -0,0 +1,10000 @@\n+line 0\n") {
+		t.Errorf("unexpected diff header:\n%.120s", got)
+	}
+	if n := strings.Count(got, "\n+line "); n != 10000 {
+		t.Errorf("expected 10000 added lines, got %d", n)
+	}
+}
+
+func TestDiffLines_FallsBackToReplaceBeyondMaxEdits(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 3*diffMaxEdits; i++ {
+		a = append(a, fmt.Sprintf("old %d", i))
+		b = append(b, fmt.Sprintf("new %d", i))
+	}
+	a = append([]string{"head"}, append(a, "tail")...)
+	b = append([]string{"head"}, append(b, "tail")...)
+
+	lines := diffLines(a, b)
+	if len(lines) != 2+6*diffMaxEdits {
+		t.Fatalf("expected %d lines, got %d", 2+6*diffMaxEdits, len(lines))
+	}
+	if lines[0] != (diffLine{' ', "head"}) || lines[1] != (diffLine{'-', "old 0"}) ||
+		lines[1+3*diffMaxEdits] != (diffLine{'+', "new 0"}) || lines[len(lines)-1] != (diffLine{' ', "tail"}) {
+		t.Errorf("expected common lines around one replace hunk, got %v ... %v", lines[:2], lines[len(lines)-1])
+	}
+}
@@ -49,29 +49,67 @@ func Run(config Config, installer *KustomizeInstaller, builder KustomizeBuilder)
 	var roots []string
 
 	excludedScanDirs := []string{".git", config.OutputDir}
+	if err := validateExcludePaths(config.ExcludePaths); err != nil {
+		return fmt.Errorf("exclude-paths: %v", err)
+	}
+	filter := newPathFilter(config.IncludePaths, config.ExcludePaths)
 
-	// Collect kustomization.yaml files
+	// Collect kustomization files
 	if config.BuildAll {
 		log.Println("🔍 Scanning for all kustomization files in the working directory...")
-		files, err := findKustomizationFilesWithExclusions(config.WorkingDir, excludedScanDirs)
-		if err != nil {
-			return fmt.Errorf("scan error: %v", err)
-		}
-		roots = kustomizationDirsFromFiles(files, config.WorkingDir)
 	} else {
 		log.Println("🔍 Scanning for root kustomization files in the working directory...")
-		files, err := findKustomizationFilesWithExclusions(config.WorkingDir, excludedScanDirs)
-		if err != nil {
-			return fmt.Errorf("scan error: %v", err)
-		}
-		roots = kustomizationDirsFromFiles(files, config.WorkingDir)
+	}
+	files, err := findKustomizationFilesFiltered(config.WorkingDir, excludedScanDirs, filter)
+	if err != nil {
+		return fmt.Errorf("scan error: %v", err)
+	}
+	files, components := splitComponentFiles(files)
+	if len(components) > 0 {
+		log.Printf("🧩 Skipping %d components (kind: Component) as build roots.", len(components))
+	}
+	roots = kustomizationDirsFromFiles(files, config.WorkingDir)
+	if !config.BuildAll {
 		log.Printf("📂 Found %d candidate kustomizations (before dedupe).", len(roots))
-
-		roots = dedupeTopLevelDirs(roots)
+		switch config.RootDetection {
+		case "", "ancestry":
+			roots = dedupeTopLevelDirs(roots)
+		case "references":
+			roots = dedupeReferencedDirs(config.WorkingDir, roots, kustomizationDirsFromFiles(components, config.WorkingDir))
+		default:
+			return fmt.Errorf("invalid root-detection %q: expected ancestry or references", config.RootDetection)
+		}
 	}
 
 	log.Printf("📦 Keeping %d kustomization files.", len(roots))
 
+	switch config.OutputLayout {
+	case "", layoutFlat, layoutSplit, layoutTree:
+	default:
+		return fmt.Errorf("invalid output-layout %q: expected flat, split or tree", config.OutputLayout)
+	}
+	if !isOutputFormat(config.OutputFormat) {
+		return fmt.Errorf("invalid output-format %q: expected yaml, json, ndjson or yaml-list", config.OutputFormat)
+	}
+
+	if config.ValidateSchemas && config.SchemaDir != "" {
+		if info, err := os.Stat(config.SchemaDir); err != nil || !info.IsDir() {
+			return fmt.Errorf("schema-dir %s is not a directory", config.SchemaDir)
+		}
+	}
+
+	if config.KubeVersion != "" {
+		if _, err := parseKubeVersion(config.KubeVersion); err != nil {
+			return fmt.Errorf("kubernetes-version: %v", err)
+		}
+	}
+
+	if len(config.PolicyRules) > 0 {
+		if _, err := selectPolicyRules(config.PolicyRules); err != nil {
+			return fmt.Errorf("policy-rules: %v", err)
+		}
+	}
+
 	// Create output dir
 	if err := os.MkdirAll(config.OutputDir, 0o755); err != nil {
 		return fmt.Errorf("cannot create output dir: %v", err)
@@ -79,17 +117,29 @@ func Run(config Config, installer *KustomizeInstaller, builder KustomizeBuilder)
 
 	// Build all roots in parallel
 	repoRoots := mapRootsToRepoRootRelative(config.WorkingDir, roots)
+
+	// Flag kustomizations that no root builds, before changed-only narrows the roots.
+	allDirs := mapRootsToRepoRootRelative(config.WorkingDir, kustomizationDirsFromFiles(append(files, components...), config.WorkingDir))
+	orphans := findOrphanKustomizations(".", repoRoots, allDirs)
+	for _, o := range orphans {
+		log.Printf("⚠️ Orphaned kustomization (not built by any root): %s", o)
+	}
+
 	if config.ChangedOnly {
-		log.Println("🧮 changed-only=true: determining changed files for last commit...")
-		changed, err := getChangedFilesLastCommit(config.WorkingDir)
+		filtered, err := selectChangedRoots(config, repoRoots)
 		if err != nil {
-			return fmt.Errorf("changed-only mode failed: %v", err)
+			return err
 		}
-		filtered := selectRootsForChangedFiles(repoRoots, changed)
-		log.Printf("🧮 changed-only: %d roots selected from %d discovered.", len(filtered), len(repoRoots))
 		repoRoots = filtered
 	}
+	if collisions := findOutputCollisions(repoRoots, config.OutputLayout, config.OutputFormat); len(collisions) > 0 {
+		for _, c := range collisions {
+			log.Printf("❌ Output name collision: %s", c)
+		}
+		return fmt.Errorf("%d roots would overwrite each other's output; use output-layout: tree", len(collisions))
+	}
 	summary := builder(repoRoots, config, kustomizePath)
+	summary.Orphans = orphans
 
 	// Write summary
 	sumBytes, _ := json.MarshalIndent(summary, "", "  ")
@@ -97,26 +147,109 @@ func Run(config Config, installer *KustomizeInstaller, builder KustomizeBuilder)
 		log.Printf("⚠️ Could not write summary: %v", err)
 	}
 	fmt.Println(string(sumBytes))
+	if err := writeStepSummary(summary); err != nil {
+		log.Printf("⚠️ Could not write step summary: %v", err)
+	}
+	emitBuildAnnotations(os.Stdout, summary.Results, config.MaxAnnotations)
+	if config.JUnitReport != "" {
+		if path, err := writeJUnitReport(config.OutputDir, config.JUnitReport, summary); err != nil {
+			log.Printf("⚠️ Could not write JUnit report: %v", err)
+		} else {
+			log.Printf("🧾 Wrote JUnit report to %s", path)
+		}
+	}
+	if config.SARIFReport != "" {
+		if path, err := writeSARIFReport(config.OutputDir, config.SARIFReport, summary); err != nil {
+			log.Printf("⚠️ Could not write SARIF report: %v", err)
+		} else {
+			log.Printf("🧾 Wrote SARIF report to %s", path)
+		}
+	}
 
-	// Count final *.yaml files (rendered only)
-	manifestCount, _ := countYAMLFiles(config.OutputDir)
+	// Count final manifest files (rendered only)
+	manifestCount, _ := countManifestFiles(config.OutputDir, config.OutputFormat,
+		config.JUnitReport, config.SARIFReport, "_summary.json", "_diff.json")
 
 	// Emit outputs for the workflow
 	setOutput("artifact-name", "kustomize-manifests")
 	setOutput("manifest-count", fmt.Sprintf("%d", manifestCount))
 	setOutput("success-count", fmt.Sprintf("%d", summary.Success))
-	setOutput("fail-count", fmt.Sprintf("%d", summary.Failed))
+	// fail-count covers every build fail-on-error fails on, timeouts included.
+	setOutput("fail-count", fmt.Sprintf("%d", summary.Failed+summary.TimedOut))
+	setOutput("timeout-count", fmt.Sprintf("%d", summary.TimedOut))
+	setOutput("schema-error-count", fmt.Sprintf("%d", summary.SchemaErrors))
+	setOutput("deprecated-api-count", fmt.Sprintf("%d", summary.DeprecatedAPIs))
+	setOutput("removed-api-count", fmt.Sprintf("%d", summary.RemovedAPIs))
+	setOutput("policy-violation-count", fmt.Sprintf("%d", summary.PolicyViolations))
 
 	rootsJSON, _ := json.Marshal(repoRoots)
 	setOutput("roots-json", string(rootsJSON))
+	orphansJSON, _ := json.Marshal(orphans)
+	setOutput("orphans-json", string(orphansJSON))
 
-	if summary.Failed > 0 && config.FailOnError {
-		return fmt.Errorf("kustomize build failed for %d roots", summary.Failed)
+	if config.Diff {
+		d, err := runManifestDiff(config, repoRoots, summary, kustomizePath, builder)
+		if err != nil {
+			return fmt.Errorf("diff mode failed: %v", err)
+		}
+		if err := writeManifestDiff(config.OutputDir, d); err != nil {
+			log.Printf("⚠️ Could not write diff: %v", err)
+		}
+		if path, err := writeDiffMarkdown(config.OutputDir, d); err != nil {
+			log.Printf("⚠️ Could not write diff comment: %v", err)
+		} else {
+			setOutput("diff-markdown", path)
+		}
+		log.Printf("🔀 diff against %s: %d added, %d removed, %d modified resources.", shortSHA(d.Base), d.Added, d.Removed, d.Modified)
+		setOutput("diff-added-count", fmt.Sprintf("%d", d.Added))
+		setOutput("diff-removed-count", fmt.Sprintf("%d", d.Removed))
+		setOutput("diff-modified-count", fmt.Sprintf("%d", d.Modified))
+	}
+
+	if failed := summary.Failed + summary.TimedOut; failed > 0 && config.FailOnError {
+		return fmt.Errorf("kustomize build failed for %d roots", failed)
+	}
+	if summary.SchemaErrors > 0 && config.FailOnError {
+		return fmt.Errorf("schema validation found %d errors", summary.SchemaErrors)
+	}
+	if summary.PolicyViolations > 0 && config.FailOnError {
+		return fmt.Errorf("policy checks found %d violations", summary.PolicyViolations)
+	}
+	if summary.RemovedAPIs > 0 && config.FailOnRemovedAPI {
+		return fmt.Errorf("found %d objects using APIs removed in Kubernetes %s", summary.RemovedAPIs, config.KubeVersion)
+	}
+	if len(orphans) > 0 && config.FailOnOrphans {
+		return fmt.Errorf("found %d orphaned kustomizations not built by any root", len(orphans))
 	}
 	// Exit code: if any failed builds, still exit 0 (let the consumer decide),
 	return nil
 }
 
+// selectChangedRoots narrows roots to those affected by the current change set.
+func selectChangedRoots(config Config, repoRoots []string) ([]string, error) {
+	cs := resolveChangeSet(config, loadGitHubEvent())
+	log.Printf("🧮 changed-only=true: determining changed files (%s)...", cs)
+	changed, err := getChangedFiles(config.WorkingDir, cs)
+	if err != nil {
+		return nil, fmt.Errorf("changed-only mode failed: %v", err)
+	}
+	if len(config.ChangedIgnore) > 0 {
+		kept := filterIgnoredFiles(changed, parseIgnoreRules(config.ChangedIgnore))
+		log.Printf("🧮 changed-ignore: ignoring %d of %d changed files.", len(changed)-len(kept), len(changed))
+		changed = kept
+	}
+
+	if pattern, file, ok := firstGlobMatch(config.RebuildAllOn, changed); ok {
+		log.Printf("🔁 rebuild-all-on: %s matched %q; building all %d roots.", file, pattern, len(repoRoots))
+		return repoRoots, nil
+	}
+
+	graph := buildDependencyGraph(".", repoRoots)
+	filtered := selectRootsForChangedFilesWithDeps(repoRoots, changed, graph)
+	log.Printf("🧮 changed-only: %d roots selected from %d discovered.", len(filtered), len(repoRoots))
+	return filtered, nil
+}
+
 func setOutput(name, value string) {
 	// GitHub Actions output
 	if path := os.Getenv("GITHUB_OUTPUT"); path != "" {
@@ -152,15 +285,23 @@ func randomHex(nBytes int) string {
 	return string(out)
 }
 
-func countYAMLFiles(dir string) (int, error) {
+// countManifestFiles counts files under dir rendered in format, skipping
+// error outputs and the report files named in reports (relative to dir).
+func countManifestFiles(dir, format string, reports ...string) (int, error) {
+	skip := map[string]bool{}
+	for _, r := range reports {
+		if r != "" {
+			skip[filepath.Join(dir, r)] = true
+		}
+	}
 	n := 0
 	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
-		if err != nil || d.IsDir() {
+		if err != nil || d.IsDir() || skip[p] {
 			return nil
 		}
 
 		base := strings.ToLower(filepath.Base(p))
-		if !strings.HasSuffix(base, ".yaml") && !strings.HasSuffix(base, ".yml") {
+		if !isManifestFileName(base, format) {
 			return nil
 		}
 		// Exclude error output files written on build failures.
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 )
@@ -59,6 +63,39 @@ func TestRun_BuildAll(t *testing.T) {
 	}
 }
 
+func TestRun_SkipsComponentsAsRoots(t *testing.T) {
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "components/x/kustomization.yaml"), "apiVersion: kustomize.config.k8s.io/v1alpha1\nkind: Component\n")
+	mustWriteFile(t, filepath.Join(tmpDir, "overlay/Kustomization"), "components:\n- ../components/x\n")
+
+	installer := &KustomizeInstaller{
+		Cmd: &MockCommandRunner{
+			LookPathFunc: func(file string) (string, error) { return "/bin/kustomize", nil },
+			RunFunc:      func(name string, args ...string) ([]byte, error) { return []byte("v5.0.0"), nil },
+		},
+		Downloader: &MockDownloader{},
+		FS:         &MockFileSystem{},
+	}
+
+	cfg := Config{
+		WorkingDir:       tmpDir,
+		OutputDir:        filepath.Join(tmpDir, "output"),
+		KustomizeVersion: "v5.0.0",
+		BuildAll:         true,
+	}
+
+	builder := func(roots []string, conf Config, kustomizePath string) Summary {
+		if len(roots) != 1 || !strings.HasSuffix(roots[0], "overlay") {
+			t.Errorf("expected only the overlay root, got %v", roots)
+		}
+		return Summary{Success: len(roots), Roots: len(roots)}
+	}
+
+	if err := Run(cfg, installer, builder); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+}
+
 func TestRun_ChangedOnly(t *testing.T) {
 	// Setup temp dir for workspace
 	tmpDir, err := os.MkdirTemp("", "workspace-changedonly")
@@ -104,6 +141,10 @@ func TestRun_ChangedOnly(t *testing.T) {
 		FS:         &MockFileSystem{},
 	}
 
+	// Make sure CI-provided event context does not change the diff range
+	t.Setenv("GITHUB_BASE_REF", "")
+	t.Setenv("GITHUB_EVENT_PATH", "")
+
 	// Change to temp dir so "." works
 	cwd, _ := os.Getwd()
 	defer os.Chdir(cwd)
@@ -137,6 +178,61 @@ func TestRun_ChangedOnly(t *testing.T) {
 	}
 }
 
+func TestRun_ChangedOnlyRebuildAllOn(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	runGit(t, tmpDir, "init")
+	runGit(t, tmpDir, "config", "user.email", "you@example.com")
+	runGit(t, tmpDir, "config", "user.name", "Your Name")
+
+	mustWriteFile(t, filepath.Join(tmpDir, "base/kustomization.yaml"), "")
+	mustWriteFile(t, filepath.Join(tmpDir, "overlay/kustomization.yaml"), "")
+	runGit(t, tmpDir, "add", ".")
+	runGit(t, tmpDir, "commit", "-m", "initial commit")
+
+	// Only a shared file outside every root changes
+	mustWriteFile(t, filepath.Join(tmpDir, "components/shared/cm.yaml"), "kind: ConfigMap\n")
+	runGit(t, tmpDir, "add", ".")
+	runGit(t, tmpDir, "commit", "-m", "touch shared component")
+
+	installer := &KustomizeInstaller{
+		Cmd: &MockCommandRunner{
+			LookPathFunc: func(file string) (string, error) { return "/bin/kustomize", nil },
+			RunFunc:      func(name string, args ...string) ([]byte, error) { return []byte("v5.0.0"), nil },
+		},
+		Downloader: &MockDownloader{},
+		FS:         &MockFileSystem{},
+	}
+
+	t.Setenv("GITHUB_BASE_REF", "")
+	t.Setenv("GITHUB_EVENT_PATH", "")
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Config{
+		WorkingDir:       ".",
+		OutputDir:        filepath.Join(tmpDir, "output"),
+		KustomizeVersion: "v5.0.0",
+		BuildAll:         true,
+		ChangedOnly:      true,
+		RebuildAllOn:     []string{"components/**"},
+	}
+
+	builder := func(roots []string, conf Config, kustomizePath string) Summary {
+		if len(roots) != 2 {
+			t.Errorf("expected all 2 roots to be rebuilt, got %d: %v", len(roots), roots)
+		}
+		return Summary{Success: len(roots), Roots: len(roots)}
+	}
+
+	if err := Run(cfg, installer, builder); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+}
+
 func TestRun_FailOnError(t *testing.T) {
 	tmpDir, _ := os.MkdirTemp("", "workspace-fail")
 	defer os.RemoveAll(tmpDir)
@@ -169,3 +265,442 @@ func TestRun_FailOnError(t *testing.T) {
 		t.Errorf("expected 'kustomize build failed', got %v", err)
 	}
 }
+
+func TestRun_RejectsMissingSchemaDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	installer := &KustomizeInstaller{
+		Cmd: &MockCommandRunner{
+			LookPathFunc: func(file string) (string, error) { return "/bin/kustomize", nil },
+			RunFunc:      func(name string, args ...string) ([]byte, error) { return []byte("v5.0.0"), nil },
+		},
+		Downloader: &MockDownloader{},
+		FS:         &MockFileSystem{},
+	}
+	cfg := Config{
+		WorkingDir:       tmpDir,
+		OutputDir:        filepath.Join(tmpDir, "output"),
+		KustomizeVersion: "v5.0.0",
+		ValidateSchemas:  true,
+		SchemaDir:        filepath.Join(tmpDir, "nope"),
+	}
+	builder := func(roots []string, conf Config, kustomizePath string) Summary {
+		t.Fatal("expected no build with a missing schema-dir")
+		return Summary{}
+	}
+
+	if err := Run(cfg, installer, builder); err == nil || !strings.Contains(err.Error(), "is not a directory") {
+		t.Fatalf("expected schema-dir error, got %v", err)
+	}
+}
+
+func TestRun_FailCountIncludesTimeouts(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputFile := filepath.Join(tmpDir, "github_output")
+	mustWriteFile(t, outputFile, "")
+	t.Setenv("GITHUB_OUTPUT", outputFile)
+
+	installer := &KustomizeInstaller{
+		Cmd: &MockCommandRunner{
+			LookPathFunc: func(file string) (string, error) { return "/bin/kustomize", nil },
+			RunFunc:      func(name string, args ...string) ([]byte, error) { return []byte("v5.0.0"), nil },
+		},
+		Downloader: &MockDownloader{},
+		FS:         &MockFileSystem{},
+	}
+	cfg := Config{
+		WorkingDir:       tmpDir,
+		OutputDir:        filepath.Join(tmpDir, "output"),
+		KustomizeVersion: "v5.0.0",
+	}
+	builder := func(roots []string, conf Config, kustomizePath string) Summary {
+		return Summary{Failed: 1, TimedOut: 2, Roots: 3}
+	}
+
+	if err := Run(cfg, installer, builder); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	b, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"fail-count": "3", "timeout-count": "2"} {
+		re := regexp.MustCompile(`(?m)^` + name + `<<(\S+)\n(.*)\n`)
+		if m := re.FindStringSubmatch(string(b)); m == nil || m[2] != want {
+			t.Errorf("expected %s=%s, got %v", name, want, m)
+		}
+	}
+}
+
+func TestRun_InvalidRootDetection(t *testing.T) {
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "app/kustomization.yaml"), "")
+
+	installer := &KustomizeInstaller{
+		Cmd: &MockCommandRunner{
+			LookPathFunc: func(file string) (string, error) { return "/bin/kustomize", nil },
+			RunFunc:      func(name string, args ...string) ([]byte, error) { return []byte("v5.0.0"), nil },
+		},
+		Downloader: &MockDownloader{},
+		FS:         &MockFileSystem{},
+	}
+
+	cfg := Config{
+		WorkingDir:       tmpDir,
+		OutputDir:        filepath.Join(tmpDir, "output"),
+		KustomizeVersion: "v5.0.0",
+		RootDetection:    "magic",
+	}
+
+	builder := func(roots []string, conf Config, kustomizePath string) Summary {
+		t.Error("builder should not be called")
+		return Summary{}
+	}
+
+	err := Run(cfg, installer, builder)
+	if err == nil || !strings.Contains(err.Error(), "root-detection") {
+		t.Fatalf("expected root-detection error, got %v", err)
+	}
+}
+
+func TestRun_FailOnOrphans(t *testing.T) {
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "apps/kustomization.yaml"), "resources:\n- a\n")
+	mustWriteFile(t, filepath.Join(tmpDir, "apps/a/kustomization.yaml"), "")
+	mustWriteFile(t, filepath.Join(tmpDir, "apps/broken/kustomization.yaml"), "")
+
+	installer := &KustomizeInstaller{
+		Cmd: &MockCommandRunner{
+			LookPathFunc: func(file string) (string, error) { return "/bin/kustomize", nil },
+			RunFunc:      func(name string, args ...string) ([]byte, error) { return []byte("v5.0.0"), nil },
+		},
+		Downloader: &MockDownloader{},
+		FS:         &MockFileSystem{},
+	}
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Config{
+		WorkingDir:       ".",
+		OutputDir:        "output",
+		KustomizeVersion: "v5.0.0",
+		FailOnOrphans:    true,
+	}
+
+	builder := func(roots []string, conf Config, kustomizePath string) Summary {
+		return Summary{Success: len(roots), Roots: len(roots)}
+	}
+
+	err := Run(cfg, installer, builder)
+	if err == nil || !strings.Contains(err.Error(), "orphaned") {
+		t.Fatalf("expected orphan error, got %v", err)
+	}
+
+	b, readErr := os.ReadFile(filepath.Join(tmpDir, "output", "_summary.json"))
+	if readErr != nil {
+		t.Fatalf("read summary: %v", readErr)
+	}
+	if !strings.Contains(string(b), `"apps/broken"`) {
+		t.Fatalf("expected apps/broken in summary orphans, got %s", b)
+	}
+}
+
+func TestRun_RemovedAPIs(t *testing.T) {
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "apps/kustomization.yaml"), "")
+
+	installer := &KustomizeInstaller{
+		Cmd: &MockCommandRunner{
+			LookPathFunc: func(file string) (string, error) { return "/bin/kustomize", nil },
+			RunFunc:      func(name string, args ...string) ([]byte, error) { return []byte("v5.0.0"), nil },
+		},
+		Downloader: &MockDownloader{},
+		FS:         &MockFileSystem{},
+	}
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	builder := func(roots []string, conf Config, kustomizePath string) Summary {
+		return Summary{Success: len(roots), Roots: len(roots), RemovedAPIs: 1}
+	}
+
+	cfg := Config{
+		WorkingDir:       ".",
+		OutputDir:        "output",
+		KustomizeVersion: "v5.0.0",
+		KubeVersion:      "1.29",
+	}
+	if err := Run(cfg, installer, builder); err != nil {
+		t.Fatalf("removed APIs must only fail when configured, got %v", err)
+	}
+
+	cfg.FailOnRemovedAPI = true
+	if err := Run(cfg, installer, builder); err == nil || !strings.Contains(err.Error(), "removed in Kubernetes 1.29") {
+		t.Fatalf("expected removed API error, got %v", err)
+	}
+
+	cfg.KubeVersion = "latest"
+	if err := Run(cfg, installer, builder); err == nil || !strings.Contains(err.Error(), "kubernetes-version") {
+		t.Fatalf("expected invalid kubernetes-version error, got %v", err)
+	}
+}
+
+func TestRun_PolicyViolations(t *testing.T) {
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "apps/kustomization.yaml"), "")
+
+	installer := &KustomizeInstaller{
+		Cmd: &MockCommandRunner{
+			LookPathFunc: func(file string) (string, error) { return "/bin/kustomize", nil },
+			RunFunc:      func(name string, args ...string) ([]byte, error) { return []byte("v5.0.0"), nil },
+		},
+		Downloader: &MockDownloader{},
+		FS:         &MockFileSystem{},
+	}
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	builder := func(roots []string, conf Config, kustomizePath string) Summary {
+		return Summary{Success: len(roots), Roots: len(roots), PolicyViolations: 2}
+	}
+
+	cfg := Config{
+		WorkingDir:       ".",
+		OutputDir:        "output",
+		KustomizeVersion: "v5.0.0",
+		PolicyRules:      []string{"image-tag"},
+	}
+	if err := Run(cfg, installer, builder); err != nil {
+		t.Fatalf("violations must only fail with fail-on-error, got %v", err)
+	}
+
+	cfg.FailOnError = true
+	if err := Run(cfg, installer, builder); err == nil || !strings.Contains(err.Error(), "2 violations") {
+		t.Fatalf("expected policy violation error, got %v", err)
+	}
+
+	cfg.PolicyRules = []string{"bogus"}
+	if err := Run(cfg, installer, builder); err == nil || !strings.Contains(err.Error(), "policy-rules") {
+		t.Fatalf("expected invalid policy-rules error, got %v", err)
+	}
+}
+
+func TestRun_DiffMode(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	runGit(t, tmpDir, "init")
+	runGit(t, tmpDir, "config", "user.email", "you@example.com")
+	runGit(t, tmpDir, "config", "user.name", "Your Name")
+
+	// The fake builder "renders" each root by copying its rendered.yaml.
+	mustWriteFile(t, filepath.Join(tmpDir, "apps/kustomization.yaml"), "")
+	mustWriteFile(t, filepath.Join(tmpDir, "apps/rendered.yaml"), "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\ndata:\n  level: info\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: gone\n")
+	runGit(t, tmpDir, "add", ".")
+	runGit(t, tmpDir, "commit", "-m", "base")
+
+	mustWriteFile(t, filepath.Join(tmpDir, "apps/rendered.yaml"), "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\ndata:\n  level: debug\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: new\n")
+	runGit(t, tmpDir, "add", ".")
+	runGit(t, tmpDir, "commit", "-m", "head")
+
+	installer := &KustomizeInstaller{
+		Cmd: &MockCommandRunner{
+			LookPathFunc: func(file string) (string, error) { return "/bin/kustomize", nil },
+			RunFunc:      func(name string, args ...string) ([]byte, error) { return []byte("v5.0.0"), nil },
+		},
+		Downloader: &MockDownloader{},
+		FS:         &MockFileSystem{},
+	}
+
+	t.Setenv("GITHUB_BASE_REF", "")
+	t.Setenv("GITHUB_EVENT_PATH", "")
+	t.Setenv("GITHUB_OUTPUT", "")
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	var builds [][]string
+	builder := func(roots []string, conf Config, kustomizePath string) Summary {
+		builds = append(builds, roots)
+		summary := Summary{Roots: len(roots), Success: len(roots)}
+		for _, r := range roots {
+			b, err := os.ReadFile(filepath.Join(r, "rendered.yaml"))
+			if err != nil {
+				t.Fatalf("read rendered.yaml of %s: %v", r, err)
+			}
+			out := filepath.Join(conf.OutputDir, sanitizeOutName(r)+"_kustomization.yaml")
+			mustWriteFile(t, out, string(b))
+			summary.Results = append(summary.Results, RootResult{Root: r, Status: statusSuccess, OutputPath: out})
+		}
+		return summary
+	}
+
+	cfg := Config{
+		WorkingDir:       ".",
+		OutputDir:        "output",
+		KustomizeVersion: "v5.0.0",
+		Diff:             true,
+	}
+	if err := Run(cfg, installer, builder); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(builds) != 2 || !strings.HasSuffix(filepath.ToSlash(builds[1][0]), "/worktree/apps") {
+		t.Fatalf("expected a head build and a base build in a worktree, got %v", builds)
+	}
+
+	var d manifestDiff
+	b, err := os.ReadFile(filepath.Join(tmpDir, "output", "_diff.json"))
+	if err != nil {
+		t.Fatalf("read _diff.json: %v", err)
+	}
+	if err := json.Unmarshal(b, &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Added != 1 || d.Removed != 1 || d.Modified != 1 || d.Base != gitRevParse(t, tmpDir, "HEAD~1") {
+		t.Fatalf("unexpected diff: %+v", d)
+	}
+	patch, err := os.ReadFile(filepath.Join(tmpDir, "output", "_diff.patch"))
+	if err != nil {
+		t.Fatalf("read _diff.patch: %v", err)
+	}
+	if !strings.Contains(string(patch), "-  level: info\n+  level: debug\n") {
+		t.Errorf("unexpected patch:\n%s", patch)
+	}
+
+	// The temporary worktree is removed again.
+	if out, _ := gitOutput(tmpDir, "worktree", "list"); strings.Count(strings.TrimSpace(out), "\n") != 0 {
+		t.Errorf("expected only the main worktree, got:\n%s", out)
+	}
+}
+
+func TestRun_DiffModeSplitLayoutEmptyRoot(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	runGit(t, tmpDir, "init")
+	runGit(t, tmpDir, "config", "user.email", "you@example.com")
+	runGit(t, tmpDir, "config", "user.name", "Your Name")
+
+	// The fake kustomize prints the rendered.yaml of the root it builds.
+	mustWriteFile(t, filepath.Join(tmpDir, "apps/kustomization.yaml"), "")
+	mustWriteFile(t, filepath.Join(tmpDir, "apps/rendered.yaml"), "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n")
+	runGit(t, tmpDir, "add", ".")
+	runGit(t, tmpDir, "commit", "-m", "base")
+
+	// The head commit removes every resource of the root.
+	mustWriteFile(t, filepath.Join(tmpDir, "apps/rendered.yaml"), "")
+	runGit(t, tmpDir, "add", ".")
+	runGit(t, tmpDir, "commit", "-m", "head")
+
+	installer := &KustomizeInstaller{
+		Cmd: &MockCommandRunner{
+			LookPathFunc: func(file string) (string, error) { return "/bin/kustomize", nil },
+			RunFunc:      func(name string, args ...string) ([]byte, error) { return []byte("v5.0.0"), nil },
+		},
+		Downloader: &MockDownloader{},
+		FS:         &MockFileSystem{},
+	}
+
+	t.Setenv("GITHUB_BASE_REF", "")
+	t.Setenv("GITHUB_EVENT_PATH", "")
+	t.Setenv("GITHUB_OUTPUT", "")
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := func(ctx context.Context, name string, args []string, stdout, stderr io.Writer) error {
+		b, err := os.ReadFile(filepath.Join(args[1], "rendered.yaml"))
+		if err != nil {
+			return err
+		}
+		_, err = stdout.Write(b)
+		return err
+	}
+	builder := func(roots []string, conf Config, kustomizePath string) Summary {
+		return buildKustomizations(roots, conf, kustomizePath, runner)
+	}
+
+	cfg := Config{
+		WorkingDir:       ".",
+		OutputDir:        "output",
+		KustomizeVersion: "v5.0.0",
+		LoadRestrictor:   "LoadRestrictionsNone",
+		Parallelism:      1,
+		OutputLayout:     layoutSplit,
+		Diff:             true,
+	}
+	if err := Run(cfg, installer, builder); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if info, err := os.Stat(filepath.Join(tmpDir, "output", "apps")); err != nil || !info.IsDir() {
+		t.Errorf("expected an empty split directory for apps, got %v", err)
+	}
+	var d manifestDiff
+	b, err := os.ReadFile(filepath.Join(tmpDir, "output", "_diff.json"))
+	if err != nil {
+		t.Fatalf("read _diff.json: %v", err)
+	}
+	if err := json.Unmarshal(b, &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Added != 0 || d.Removed != 1 || d.Modified != 0 {
+		t.Fatalf("expected the ConfigMap to be removed, got %+v", d)
+	}
+}
+
+func TestRun_FailsOnOutputNameCollision(t *testing.T) {
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "apps/foo_bar/kustomization.yaml"), "")
+	mustWriteFile(t, filepath.Join(tmpDir, "apps_foo/bar/kustomization.yaml"), "")
+
+	installer := &KustomizeInstaller{
+		Cmd: &MockCommandRunner{
+			LookPathFunc: func(file string) (string, error) { return "/bin/kustomize", nil },
+			RunFunc:      func(name string, args ...string) ([]byte, error) { return []byte("v5.0.0"), nil },
+		},
+		Downloader: &MockDownloader{},
+		FS:         &MockFileSystem{},
+	}
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	built := false
+	builder := func(roots []string, conf Config, kustomizePath string) Summary {
+		built = true
+		return Summary{Success: len(roots), Roots: len(roots)}
+	}
+
+	cfg := Config{WorkingDir: ".", OutputDir: "output", KustomizeVersion: "v5.0.0"}
+	if err := Run(cfg, installer, builder); err == nil || !strings.Contains(err.Error(), "output-layout: tree") {
+		t.Fatalf("expected collision error, got %v", err)
+	}
+	if built {
+		t.Fatal("expected no build when outputs collide")
+	}
+
+	cfg.OutputLayout = layoutTree
+	if err := Run(cfg, installer, builder); err != nil {
+		t.Fatalf("tree layout must not collide, got %v", err)
+	}
+}
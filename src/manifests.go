@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// manifest is a single rendered Kubernetes object.
+type manifest struct {
+	APIVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+	Object     map[string]interface{}
+	// node is the parsed document, used to re-encode it with its original field order.
+	node *yaml.Node
+}
+
+// GVK returns the "apiVersion/kind" key used for per-kind counts.
+func (m manifest) GVK() string {
+	return m.APIVersion + "/" + m.Kind
+}
+
+// ResourceID identifies the object as "<kind>/<namespace>/<name>", or
+// "<kind>/<name>" when it has no namespace.
+func (m manifest) ResourceID() string {
+	if m.Namespace == "" {
+		return m.Kind + "/" + m.Name
+	}
+	return m.Kind + "/" + m.Namespace + "/" + m.Name
+}
+
+// parseManifests splits a multi-document YAML stream into objects, skipping empty documents.
+func parseManifests(data []byte) ([]manifest, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	var out []manifest
+	for {
+		var node yaml.Node
+		err := dec.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return out, err
+		}
+		obj, err := decodeManifestNode(&node)
+		if err != nil {
+			return out, err
+		}
+		if len(obj) == 0 {
+			continue
+		}
+		out = append(out, newManifest(obj, &node))
+	}
+	return out, nil
+}
+
+// decodeManifestNode decodes node like sigs.k8s.io/yaml does for kubectl:
+// unquoted timestamps such as 2024-01-01 stay strings instead of becoming
+// time.Time values. node itself is left unchanged.
+func decodeManifestNode(node *yaml.Node) (map[string]interface{}, error) {
+	var timestamps []*yaml.Node
+	var collect func(n *yaml.Node)
+	collect = func(n *yaml.Node) {
+		if n.Kind == yaml.ScalarNode && n.ShortTag() == "!!timestamp" && n.Style&yaml.TaggedStyle == 0 {
+			timestamps = append(timestamps, n)
+		}
+		for _, c := range n.Content {
+			collect(c)
+		}
+	}
+	collect(node)
+
+	tags := make([]string, len(timestamps))
+	for i, n := range timestamps {
+		tags[i], n.Tag = n.Tag, "!!str"
+	}
+	defer func() {
+		for i, n := range timestamps {
+			n.Tag = tags[i]
+		}
+	}()
+
+	var obj map[string]interface{}
+	err := node.Decode(&obj)
+	return obj, err
+}
+
+func newManifest(obj map[string]interface{}, node *yaml.Node) manifest {
+	m := manifest{Object: obj, node: node}
+	m.APIVersion, _ = obj["apiVersion"].(string)
+	m.Kind, _ = obj["kind"].(string)
+	if md, ok := obj["metadata"].(map[string]interface{}); ok {
+		m.Name, _ = md["name"].(string)
+		m.Namespace, _ = md["namespace"].(string)
+	}
+	return m
+}
+
+// countKinds returns the number of manifests per apiVersion/kind.
+func countKinds(docs []manifest) map[string]int {
+	if len(docs) == 0 {
+		return nil
+	}
+	kinds := make(map[string]int)
+	for _, d := range docs {
+		kinds[d.GVK()]++
+	}
+	return kinds
+}
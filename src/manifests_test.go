@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseManifests(t *testing.T) {
+	stream := `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: prod
+---
+# only a comment
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: prod
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+`
+	docs, err := parseManifests([]byte(stream))
+	if err != nil {
+		t.Fatalf("parseManifests: %v", err)
+	}
+	if len(docs) != 3 {
+		t.Fatalf("expected 3 documents, got %d", len(docs))
+	}
+	first := docs[0]
+	if first.APIVersion != "apps/v1" || first.Kind != "Deployment" || first.Name != "web" || first.Namespace != "prod" {
+		t.Fatalf("unexpected first document: %+v", first)
+	}
+
+	expected := map[string]int{"apps/v1/Deployment": 1, "v1/Service": 2}
+	if got := countKinds(docs); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestParseManifests_InvalidYAML(t *testing.T) {
+	if _, err := parseManifests([]byte("kind: [unterminated\n")); err == nil {
+		t.Fatalf("expected parse error")
+	}
+	if docs, err := parseManifests(nil); err != nil || len(docs) != 0 {
+		t.Fatalf("expected no documents and no error for empty input, got %v, %v", docs, err)
+	}
+}
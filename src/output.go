@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output layouts selected by the output-layout input.
+const (
+	// layoutFlat writes one <root>_kustomization.yaml stream per root.
+	layoutFlat = "flat"
+	// layoutSplit writes <root>/<namespace>/<kind>-<name>.yaml per object.
+	layoutSplit = "split"
+	// layoutTree mirrors the source tree: <root path>/manifest.yaml.
+	layoutTree = "tree"
+)
+
+// Tree layout file names. The manifest extension follows the output format.
+const (
+	treeManifestFile = "manifest.yaml"
+	treeErrorFile    = "error.log"
+)
+
+// clusterScopedDir holds split output of objects without a namespace.
+const clusterScopedDir = "_cluster"
+
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// writeRenderedOutput stores the rendered output of dir as configured by
+// opts.Layout and opts.Format and returns the file or directory written.
+func writeRenderedOutput(opts buildOptions, dir, outPath string, rendered []byte, docs []manifest, parseErr error) (string, error) {
+	if opts.Layout == layoutSplit {
+		if parseErr != nil {
+			return "", fmt.Errorf("cannot split unparseable output: %v", parseErr)
+		}
+		splitDir := filepath.Join(opts.OutputDir, sanitizeOutName(dir))
+		return splitDir, writeSplitManifests(splitDir, docs, opts.Format)
+	}
+	b, err := convertRendered(rendered, docs, parseErr, opts.Format)
+	if err != nil {
+		return "", err
+	}
+	if opts.Layout == layoutTree {
+		p := filepath.Join(treeOutputDir(opts.OutputDir, dir), withFormatExt(treeManifestFile, opts.Format))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return "", err
+		}
+		return p, os.WriteFile(p, b, 0o644)
+	}
+	return outPath, os.WriteFile(outPath, b, 0o644)
+}
+
+// flatOutputName returns the YAML file name of the flat output of root dir,
+// <root>_<kustomization file name>, or "" when dir has no kustomization.
+func flatOutputName(dir string) string {
+	buildDir := dir
+	if buildDir == "" {
+		buildDir = "."
+	}
+	path := kustomizationFileIn(buildDir)
+	if path == "" {
+		return ""
+	}
+	fileName := filepath.Base(path)
+	if fileName == "Kustomization" {
+		fileName = "kustomization.yaml"
+	}
+	return sanitizeOutName(dir) + "_" + fileName
+}
+
+// errorOutputPath returns where the stderr of a failed build of dir is kept:
+// error.log in the tree layout, otherwise outName with an -err suffix
+// (<root>_kustomization-err.yaml).
+func errorOutputPath(opts buildOptions, dir, outName string) string {
+	if opts.Layout == layoutTree {
+		return filepath.Join(treeOutputDir(opts.OutputDir, dir), treeErrorFile)
+	}
+	errOut := strings.TrimSuffix(outName, ".yaml")
+	errOut = strings.TrimSuffix(errOut, ".yml")
+	if strings.HasSuffix(outName, ".yaml") {
+		errOut += "-err.yaml"
+	} else {
+		errOut += "-err.yml"
+	}
+	return filepath.Join(opts.OutputDir, errOut)
+}
+
+// treeOutputDir mirrors the repo-relative root dir below outputDir. Roots
+// outside the working directory (e.g. base builds in a worktree) fall back
+// to the flattened name.
+func treeOutputDir(outputDir, dir string) string {
+	rel := filepath.Clean(dir)
+	if filepath.IsAbs(rel) {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return filepath.Join(outputDir, sanitizeOutName(dir))
+		}
+		if rel, err = filepath.Rel(cwd, rel); err != nil {
+			return filepath.Join(outputDir, sanitizeOutName(dir))
+		}
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.Join(outputDir, sanitizeOutName(dir))
+	}
+	return filepath.Join(outputDir, rel)
+}
+
+// findOutputCollisions reports roots whose flattened output names clash,
+// e.g. apps/foo_bar and apps_foo/bar both writing apps_foo_bar_kustomization.yaml.
+// Names are computed as buildRoot does: the split directory, or the flat file
+// name in format. The tree layout cannot collide.
+func findOutputCollisions(roots []string, layout, format string) []string {
+	if layout == layoutTree {
+		return nil
+	}
+	owner := map[string]string{}
+	var out []string
+	for _, r := range roots {
+		name := flatOutputName(r)
+		if name == "" {
+			continue
+		}
+		if layout == layoutSplit {
+			name = sanitizeOutName(r)
+		} else {
+			name = withFormatExt(name, format)
+		}
+		if prev, ok := owner[name]; ok {
+			out = append(out, fmt.Sprintf("%s and %s both write %s", displayRoot(prev), displayRoot(r), name))
+			continue
+		}
+		owner[name] = r
+	}
+	return out
+}
+
+// writeSplitManifests writes every object to <namespace>/<kind>-<name>.yaml
+// below dir, in document order, with the extension of format. Each file holds
+// the bare object, never a List. Objects that map to the same file name get a
+// numeric suffix ("-2", "-3", ...).
+func writeSplitManifests(dir string, docs []manifest, format string) error {
+	ext := formatExt(format)
+	// A root rendering nothing still gets its (empty) directory.
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	used := map[string]bool{}
+	for _, doc := range docs {
+		ns := doc.Namespace
+		if ns == "" {
+			ns = clusterScopedDir
+		}
+		base := filepath.Join(safeFileName(ns, "default"), safeFileName(strings.ToLower(doc.Kind), "unknown")+"-"+safeFileName(doc.Name, "unnamed"))
+		name := base + ext
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s-%d%s", base, n, ext)
+		}
+		used[name] = true
+
+		b, err := encodeManifest(doc, format)
+		if err != nil {
+			return err
+		}
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(p, b, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeManifestYAML renders a single object as YAML, keeping the field order
+// of the kustomize output when the source node is available.
+func encodeManifestYAML(doc manifest) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	var err error
+	if doc.node != nil {
+		err = enc.Encode(doc.node)
+	} else {
+		err = enc.Encode(doc.Object)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// safeFileName replaces characters that are awkward in file names, e.g. the
+// colons of "system:controller" RBAC names. Empty names become fallback.
+func safeFileName(s, fallback string) string {
+	s = strings.Trim(unsafeFileNameChars.ReplaceAllString(s, "_"), ".")
+	if s == "" {
+		return fallback
+	}
+	return s
+}
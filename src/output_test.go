@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestWriteSplitManifests(t *testing.T) {
+	dir := t.TempDir()
+	docs := mustParseManifests(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: prod
+spec:
+  replicas: 2
+---
+apiVersion: extensions/v1beta1
+kind: Deployment
+metadata:
+  name: web
+  namespace: prod
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: system:controller
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  namespace: prod
+`)
+
+	if err := writeSplitManifests(dir, docs, formatYAML); err != nil {
+		t.Fatalf("writeSplitManifests: %v", err)
+	}
+
+	var got []string
+	filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			rel, _ := filepath.Rel(dir, p)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(got)
+	want := []string{
+		"_cluster/clusterrole-system_controller.yaml",
+		"prod/configmap-unnamed.yaml",
+		"prod/deployment-web-2.yaml",
+		"prod/deployment-web.yaml",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got files:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "prod", "deployment-web.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The original field order and indentation are kept.
+	wantDoc := "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n  namespace: prod\nspec:\n  replicas: 2\n"
+	if string(b) != wantDoc {
+		t.Errorf("got:\n%s\nwant:\n%s", b, wantDoc)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "prod", "deployment-web-2.yaml")); !strings.Contains(string(b), "extensions/v1beta1") {
+		t.Errorf("expected colliding object in suffixed file, got:\n%s", b)
+	}
+}
+
+func TestBuildKustomizations_SplitLayoutCountsResources(t *testing.T) {
+	tmpDir := t.TempDir()
+	outDir := filepath.Join(tmpDir, "out")
+	app := filepath.Join(tmpDir, "app")
+	for _, d := range []string{outDir, app} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeKustomizationYAML(t, app)
+
+	rendered := "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: prod\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n  namespace: prod\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: b\n  namespace: prod\n"
+	conf := Config{OutputDir: outDir, LoadRestrictor: "LoadRestrictionsNone", Parallelism: 1, OutputLayout: layoutSplit}
+	summary := buildKustomizations([]string{app}, conf, "kustomize", fakeRenderRunner(rendered))
+
+	res := summary.Results[0]
+	if res.Status != statusSuccess || res.OutputPath != filepath.Join(outDir, sanitizeOutName(app)) {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if n, err := countManifestFiles(outDir, formatYAML); err != nil || n != 3 {
+		t.Fatalf("expected 3 resource files, got %d (%v)", n, err)
+	}
+	docs, err := readRenderedManifests(res.OutputPath, layoutSplit)
+	if err != nil || len(docs) != 3 {
+		t.Fatalf("expected split output to read back as 3 objects, got %d (%v)", len(docs), err)
+	}
+}
+
+func TestSafeFileName(t *testing.T) {
+	for in, want := range map[string]string{
+		"web":               "web",
+		"system:controller": "system_controller",
+		"a/b c":             "a_b_c",
+		"..":                "x",
+		"":                  "x",
+	} {
+		if got := safeFileName(in, "x"); got != want {
+			t.Errorf("safeFileName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestBuildKustomizations_TreeLayout(t *testing.T) {
+	chdirTemp(t)
+	for _, d := range []string{"out", "apps/foo/bar", "apps/bad"} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeKustomizationYAML(t, "apps/foo/bar")
+	writeKustomizationYAML(t, "apps/bad")
+
+	runner := func(ctx context.Context, name string, args []string, stdout, stderr io.Writer) error {
+		if args[1] == "apps/bad" {
+			_, _ = io.WriteString(stderr, "Error: boom\n")
+			return errors.New("exit status 1")
+		}
+		_, err := io.WriteString(stdout, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n")
+		return err
+	}
+	conf := Config{OutputDir: "out", LoadRestrictor: "LoadRestrictionsNone", Parallelism: 1, OutputLayout: layoutTree}
+	summary := buildKustomizations([]string{"apps/foo/bar", "apps/bad"}, conf, "kustomize", runner)
+
+	if got := summary.Results[0].OutputPath; got != filepath.Join("out", "apps", "foo", "bar", "manifest.yaml") {
+		t.Errorf("unexpected manifest path %q", got)
+	}
+	if b, err := os.ReadFile(filepath.Join("out", "apps", "foo", "bar", "manifest.yaml")); err != nil || !strings.Contains(string(b), "kind: ConfigMap") {
+		t.Errorf("expected rendered manifest, got %q (%v)", b, err)
+	}
+	if got := summary.Results[1].OutputPath; got != filepath.Join("out", "apps", "bad", "error.log") {
+		t.Errorf("unexpected error path %q", got)
+	}
+	if b, err := os.ReadFile(filepath.Join("out", "apps", "bad", "error.log")); err != nil || string(b) != "Error: boom\n" {
+		t.Errorf("expected stderr in error.log, got %q (%v)", b, err)
+	}
+	if n, _ := countManifestFiles("out", formatYAML); n != 1 {
+		t.Errorf("expected 1 manifest, got %d", n)
+	}
+}
+
+func TestTreeOutputDir_FallsBackOutsideWorkingDir(t *testing.T) {
+	tmpDir := chdirTemp(t)
+	if got := treeOutputDir("out", "apps/web"); got != filepath.Join("out", "apps", "web") {
+		t.Errorf("got %q", got)
+	}
+	if got := treeOutputDir("out", "."); got != "out" {
+		t.Errorf("got %q", got)
+	}
+	if got := treeOutputDir("out", filepath.Join(tmpDir, "apps", "web")); got != filepath.Join("out", "apps", "web") {
+		t.Errorf("expected absolute root inside the working directory to be mirrored, got %q", got)
+	}
+	outside := filepath.Join(t.TempDir(), "worktree", "apps")
+	if got := treeOutputDir("out", outside); got != filepath.Join("out", sanitizeOutName(outside)) {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestFindOutputCollisions(t *testing.T) {
+	chdirTemp(t)
+	for _, f := range []string{"apps/foo_bar/kustomization.yaml", "apps/web/kustomization.yaml", "apps_foo/bar/kustomization.yaml", "apps/x_y/kustomization.yml", "apps_x/y/kustomization.yaml"} {
+		mustWriteFile(t, f, "resources: []\n")
+	}
+	roots := []string{"apps/foo_bar", "apps/web", "apps_foo/bar", "apps/missing"}
+	got := findOutputCollisions(roots, layoutFlat, formatYAML)
+	if len(got) != 1 || got[0] != "apps/foo_bar and apps_foo/bar both write apps_foo_bar_kustomization.yaml" {
+		t.Errorf("unexpected collisions %v", got)
+	}
+	if got := findOutputCollisions(roots, layoutTree, formatYAML); len(got) != 0 {
+		t.Errorf("tree layout cannot collide, got %v", got)
+	}
+
+	// kustomization.yml and kustomization.yaml only collide once both become .json.
+	roots = []string{"apps/x_y", "apps_x/y"}
+	if got := findOutputCollisions(roots, layoutFlat, formatYAML); len(got) != 0 {
+		t.Errorf("expected distinct flat names, got %v", got)
+	}
+	if got := findOutputCollisions(roots, layoutFlat, formatJSON); len(got) != 1 || !strings.HasSuffix(got[0], "apps_x_y_kustomization.json") {
+		t.Errorf("expected a json collision, got %v", got)
+	}
+	if got := findOutputCollisions(roots, layoutSplit, formatYAML); len(got) != 1 || !strings.HasSuffix(got[0], "both write apps_x_y") {
+		t.Errorf("expected a split directory collision, got %v", got)
+	}
+}
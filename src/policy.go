@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// policyRule is a built-in check applied to every rendered object.
+type policyRule struct {
+	ID          string
+	Description string
+	// check returns one message per violation in m.
+	check func(m manifest) []string
+}
+
+// policyRules lists the built-in rules selectable via the policy-rules input.
+var policyRules = []policyRule{
+	{"image-tag", "container images must be pinned to a tag other than latest, or a digest", checkImageTag},
+	{"resources", "containers must declare resource requests and limits", checkResources},
+	{"privileged", "containers must not run privileged", checkPrivileged},
+	{"host-path", "pods must not mount hostPath volumes", checkHostPath},
+	{"recommended-labels", "workloads must carry the app.kubernetes.io/name and app.kubernetes.io/instance labels", checkRecommendedLabels},
+}
+
+// recommendedLabels are the app.kubernetes.io labels the recommended-labels rule requires.
+var recommendedLabels = []string{"app.kubernetes.io/name", "app.kubernetes.io/instance"}
+
+func isPolicyRule(id string) bool {
+	for _, r := range policyRules {
+		if r.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// selectPolicyRules resolves the policy-rules input. "all" selects every rule.
+func selectPolicyRules(ids []string) ([]policyRule, error) {
+	wanted := map[string]bool{}
+	for _, id := range ids {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if id == "all" {
+			return policyRules, nil
+		}
+		if !isPolicyRule(id) {
+			var known []string
+			for _, r := range policyRules {
+				known = append(known, r.ID)
+			}
+			return nil, fmt.Errorf("unknown policy rule %q (known: %s, all)", id, strings.Join(known, ", "))
+		}
+		wanted[id] = true
+	}
+	var out []policyRule
+	for _, r := range policyRules {
+		if wanted[r.ID] {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+// policyChecker applies the selected policy rules to rendered objects.
+type policyChecker struct {
+	rules []policyRule
+}
+
+func (c *policyChecker) checkManifests(root string, docs []manifest) []Finding {
+	var out []Finding
+	for _, doc := range docs {
+		for _, r := range c.rules {
+			for _, msg := range r.check(doc) {
+				out = append(out, Finding{
+					Rule:     r.ID,
+					Level:    levelError,
+					Message:  doc.ResourceID() + ": " + msg,
+					Resource: doc.ResourceID(),
+				})
+			}
+		}
+	}
+	return out
+}
+
+// podSpec returns the pod spec embedded in workload objects, or nil.
+func podSpec(m manifest) map[string]interface{} {
+	spec := mapField(m.Object, "spec")
+	switch m.Kind {
+	case "Pod":
+		return spec
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "ReplicationController", "Job":
+		return mapField(mapField(spec, "template"), "spec")
+	case "CronJob":
+		return mapField(mapField(mapField(mapField(spec, "jobTemplate"), "spec"), "template"), "spec")
+	}
+	return nil
+}
+
+// podContainers returns the named containers of a pod spec, init containers first.
+func podContainers(spec map[string]interface{}, includeInit bool) []map[string]interface{} {
+	fields := []string{"containers"}
+	if includeInit {
+		fields = []string{"initContainers", "containers"}
+	}
+	var out []map[string]interface{}
+	for _, f := range fields {
+		list, _ := spec[f].([]interface{})
+		for _, c := range list {
+			if cm, ok := c.(map[string]interface{}); ok {
+				out = append(out, cm)
+			}
+		}
+	}
+	return out
+}
+
+func mapField(m map[string]interface{}, key string) map[string]interface{} {
+	v, _ := m[key].(map[string]interface{})
+	return v
+}
+
+func containerName(c map[string]interface{}) string {
+	name, _ := c["name"].(string)
+	return name
+}
+
+func checkImageTag(m manifest) []string {
+	var out []string
+	for _, c := range podContainers(podSpec(m), true) {
+		image, _ := c["image"].(string)
+		if image == "" || strings.Contains(image, "@") {
+			continue
+		}
+		// The tag follows the last colon after the last slash (a colon before it is a registry port).
+		name := image[strings.LastIndex(image, "/")+1:]
+		tag := ""
+		if i := strings.LastIndex(name, ":"); i >= 0 {
+			tag = name[i+1:]
+		}
+		switch tag {
+		case "":
+			out = append(out, fmt.Sprintf("container %q uses untagged image %q", containerName(c), image))
+		case "latest":
+			out = append(out, fmt.Sprintf("container %q uses image %q with the latest tag", containerName(c), image))
+		}
+	}
+	return out
+}
+
+func checkResources(m manifest) []string {
+	var out []string
+	for _, c := range podContainers(podSpec(m), false) {
+		res := mapField(c, "resources")
+		var missing []string
+		for _, k := range []string{"requests", "limits"} {
+			if len(mapField(res, k)) == 0 {
+				missing = append(missing, k)
+			}
+		}
+		if len(missing) > 0 {
+			out = append(out, fmt.Sprintf("container %q has no resource %s", containerName(c), strings.Join(missing, " or ")))
+		}
+	}
+	return out
+}
+
+func checkPrivileged(m manifest) []string {
+	var out []string
+	for _, c := range podContainers(podSpec(m), true) {
+		if mapField(c, "securityContext")["privileged"] == true {
+			out = append(out, fmt.Sprintf("container %q runs privileged", containerName(c)))
+		}
+	}
+	return out
+}
+
+func checkHostPath(m manifest) []string {
+	var out []string
+	volumes, _ := podSpec(m)["volumes"].([]interface{})
+	for _, v := range volumes {
+		vm, _ := v.(map[string]interface{})
+		if hp := mapField(vm, "hostPath"); hp != nil {
+			name, _ := vm["name"].(string)
+			path, _ := hp["path"].(string)
+			out = append(out, fmt.Sprintf("volume %q mounts hostPath %q", name, path))
+		}
+	}
+	return out
+}
+
+func checkRecommendedLabels(m manifest) []string {
+	if podSpec(m) == nil && m.Kind != "Service" {
+		return nil
+	}
+	labels := mapField(mapField(m.Object, "metadata"), "labels")
+	var missing []string
+	for _, l := range recommendedLabels {
+		if v, _ := labels[l].(string); v == "" {
+			missing = append(missing, l)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return []string{"missing labels " + strings.Join(missing, ", ")}
+}
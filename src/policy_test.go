@@ -0,0 +1,114 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const policyFixture = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: prod
+  labels:
+    app.kubernetes.io/name: web
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: busybox
+      containers:
+      - name: app
+        image: registry.local:5000/team/web:latest
+        securityContext:
+          privileged: true
+      - name: sidecar
+        image: envoy@sha256:abc
+        resources:
+          requests: {cpu: 10m}
+          limits: {memory: 64Mi}
+      volumes:
+      - name: docker
+        hostPath:
+          path: /var/run/docker.sock
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: report
+  labels:
+    app.kubernetes.io/name: report
+    app.kubernetes.io/instance: report-prod
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: job
+            image: registry.local:5000/report
+            resources:
+              requests: {cpu: 10m}
+              limits: {cpu: 1}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+`
+
+func TestPolicyChecker_AllRules(t *testing.T) {
+	docs, err := parseManifests([]byte(policyFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules, err := selectPolicyRules([]string{"all"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, f := range (&policyChecker{rules: rules}).checkManifests("apps/web", docs) {
+		got = append(got, f.Rule+"|"+f.Message)
+		if f.Level != levelError || f.Resource == "" {
+			t.Errorf("unexpected finding %+v", f)
+		}
+	}
+	want := []string{
+		`image-tag|Deployment/prod/web: container "init" uses untagged image "busybox"`,
+		`image-tag|Deployment/prod/web: container "app" uses image "registry.local:5000/team/web:latest" with the latest tag`,
+		`resources|Deployment/prod/web: container "app" has no resource requests or limits`,
+		`privileged|Deployment/prod/web: container "app" runs privileged`,
+		`host-path|Deployment/prod/web: volume "docker" mounts hostPath "/var/run/docker.sock"`,
+		`recommended-labels|Deployment/prod/web: missing labels app.kubernetes.io/instance`,
+		`image-tag|CronJob/report: container "job" uses untagged image "registry.local:5000/report"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestSelectPolicyRules(t *testing.T) {
+	rules, err := selectPolicyRules([]string{" Privileged ", "image-tag"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 || rules[0].ID != "image-tag" || rules[1].ID != "privileged" {
+		t.Errorf("unexpected rules %+v", rules)
+	}
+
+	if _, err := selectPolicyRules([]string{"no-root"}); err == nil || !strings.Contains(err.Error(), `unknown policy rule "no-root"`) {
+		t.Errorf("expected unknown rule error, got %v", err)
+	}
+}
+
+func TestCountPolicyViolations(t *testing.T) {
+	findings := []Finding{{Rule: "image-tag"}, {Rule: "schema-invalid"}, {Rule: "host-path"}, {Rule: "api-removed"}}
+	if n := countPolicyViolations(findings); n != 2 {
+		t.Errorf("expected 2 policy violations, got %d", n)
+	}
+	if d := ruleDescription("host-path"); d != "pods must not mount hostPath volumes" {
+		t.Errorf("unexpected description %q", d)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// stepSummaryTailLines is how many stderr lines of a failed root the step summary shows.
+	stepSummaryTailLines = 20
+	// stepSummaryMaxFindings caps the findings table of the step summary.
+	stepSummaryMaxFindings = 50
+)
+
+// renderStepSummary renders the Markdown build report for GITHUB_STEP_SUMMARY.
+func renderStepSummary(summary Summary) string {
+	var b strings.Builder
+
+	documents := 0
+	for _, r := range summary.Results {
+		documents += r.Documents
+	}
+
+	b.WriteString("## Kustomize build report\n\n")
+	b.WriteString("| Roots | ✅ Success | ❌ Failed | ⏱️ Timed out | ⏭️ Canceled | Resources |\n")
+	b.WriteString("| ---: | ---: | ---: | ---: | ---: | ---: |\n")
+	fmt.Fprintf(&b, "| %d | %d | %d | %d | %d | %d |\n\n", summary.Roots, summary.Success, summary.Failed, summary.TimedOut, summary.Canceled, documents)
+
+	var checks []string
+	for _, c := range []struct {
+		n    int
+		what string
+	}{
+		{summary.SchemaErrors, "schema errors"},
+		{summary.RemovedAPIs, "removed APIs"},
+		{summary.DeprecatedAPIs, "deprecated APIs"},
+		{summary.PolicyViolations, "policy violations"},
+	} {
+		if c.n > 0 {
+			checks = append(checks, fmt.Sprintf("%d %s", c.n, c.what))
+		}
+	}
+	if len(checks) > 0 {
+		fmt.Fprintf(&b, "**Checks:** %s\n\n", strings.Join(checks, " · "))
+	}
+
+	if len(summary.Results) > 0 {
+		b.WriteString("| Root | Status | Duration | Resources |\n")
+		b.WriteString("| :--- | :--- | ---: | ---: |\n")
+		for _, r := range summary.Results {
+			fmt.Fprintf(&b, "| %s | %s | %.1fs | %d |\n", markdownCode(displayRoot(r.Root)), statusLabel(r.Status), r.DurationSeconds, r.Documents)
+		}
+		b.WriteString("\n")
+	}
+
+	var failures []RootResult
+	for _, r := range summary.Results {
+		if r.Status == statusFailed || r.Status == statusTimedOut {
+			failures = append(failures, r)
+		}
+	}
+	if len(failures) > 0 {
+		b.WriteString("### Failures\n\n")
+		for _, r := range failures {
+			fmt.Fprintf(&b, "<details>\n<summary><code>%s</code> (%s)</summary>\n\n", htmlEscape(displayRoot(r.Root)), r.Status)
+			out := strings.TrimSpace(tail(r.Stderr, stepSummaryTailLines))
+			if out == "" {
+				out = "(no output)"
+			}
+			fence := codeFence(out)
+			fmt.Fprintf(&b, "%stext\n%s\n%s\n\n</details>\n\n", fence, out, fence)
+		}
+	}
+
+	var shown, total int
+	for _, r := range summary.Results {
+		total += len(r.Findings)
+	}
+	if total > 0 {
+		fmt.Fprintf(&b, "### Findings (%d)\n\n", total)
+		b.WriteString("| Root | Level | Rule | Message |\n")
+		b.WriteString("| :--- | :--- | :--- | :--- |\n")
+		for _, r := range summary.Results {
+			for _, f := range r.Findings {
+				if shown == stepSummaryMaxFindings {
+					break
+				}
+				shown++
+				fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", markdownCode(displayRoot(r.Root)), f.Level, f.Rule, markdownCell(f.Message))
+			}
+		}
+		if total > shown {
+			fmt.Fprintf(&b, "\n…and %d more findings, see `_summary.json`.\n", total-shown)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(summary.Orphans) > 0 {
+		b.WriteString("### ⚠️ Orphaned kustomizations\n\n")
+		for _, o := range summary.Orphans {
+			fmt.Fprintf(&b, "- %s\n", markdownCode(displayRoot(o)))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// writeStepSummary appends the build report to the file named by GITHUB_STEP_SUMMARY, if set.
+func writeStepSummary(summary Summary) error {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(renderStepSummary(summary))
+	return err
+}
+
+func statusLabel(status string) string {
+	switch status {
+	case statusSuccess:
+		return "✅ success"
+	case statusFailed:
+		return "❌ failed"
+	case statusTimedOut:
+		return "⏱️ timed out"
+	case statusCanceled:
+		return "⏭️ canceled"
+	}
+	return status
+}
+
+func displayRoot(root string) string {
+	if root == "" {
+		return "."
+	}
+	return root
+}
+
+func markdownCode(s string) string {
+	return "`" + strings.ReplaceAll(s, "|", "\\|") + "`"
+}
+
+// markdownCell makes s safe to place inside a table cell.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+func htmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
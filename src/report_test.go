@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderStepSummary_TableTotalsAndFailures(t *testing.T) {
+	summary := Summary{
+		Success: 1,
+		Failed:  1,
+		Roots:   2,
+		Orphans: []string{"unused"},
+		Results: []RootResult{
+			{Root: "apps/web", Status: statusSuccess, DurationSeconds: 1.25, Documents: 3},
+			{Root: "apps/a|b", Status: statusFailed, DurationSeconds: 0.5, Stderr: "line1\nError: accumulating resources\n"},
+		},
+	}
+
+	got := renderStepSummary(summary)
+
+	for _, want := range []string{
+		"| 2 | 1 | 1 | 0 | 0 | 3 |",
+		"| `apps/web` | ✅ success | 1.2s | 3 |",
+		"| `apps/a\\|b` | ❌ failed | 0.5s | 0 |",
+		"<summary><code>apps/a|b</code> (failed)</summary>",
+		"Error: accumulating resources",
+		"- `unused`",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected report to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestRenderStepSummary_TailsLongStderr(t *testing.T) {
+	var lines []string
+	for i := 0; i < stepSummaryTailLines+5; i++ {
+		lines = append(lines, "noise")
+	}
+	lines = append(lines, "final error")
+	lines[0] = "first line"
+
+	got := renderStepSummary(Summary{Results: []RootResult{
+		{Root: "bad", Status: statusTimedOut, Stderr: strings.Join(lines, "\n")},
+	}})
+
+	if strings.Contains(got, "first line") {
+		t.Errorf("expected stderr to be tailed, got:\n%s", got)
+	}
+	if !strings.Contains(got, "final error") {
+		t.Errorf("expected last stderr line in report, got:\n%s", got)
+	}
+}
+
+func TestRenderStepSummary_FencesStderrWithBackticks(t *testing.T) {
+	got := renderStepSummary(Summary{Results: []RootResult{
+		{Root: "bad", Status: statusFailed, Stderr: "Error: template: values.yaml:\n```\nfoo: {{ .bar }}\n```"},
+	}})
+	if !strings.Contains(got, "````text\nError: template: values.yaml:\n```\nfoo: {{ .bar }}\n```\n````\n") {
+		t.Errorf("expected stderr inside a longer fence, got:\n%s", got)
+	}
+}
+
+func TestWriteStepSummary_AppendsToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "step_summary.md")
+	mustWriteFile(t, path, "existing\n")
+	t.Setenv("GITHUB_STEP_SUMMARY", path)
+
+	if err := writeStepSummary(Summary{Roots: 1, Success: 1}); err != nil {
+		t.Fatalf("writeStepSummary: %v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(b), "existing\n## Kustomize build report") {
+		t.Errorf("expected report appended after existing content, got:\n%s", b)
+	}
+}
+
+func TestWriteStepSummary_NoopWithoutEnv(t *testing.T) {
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+	if err := writeStepSummary(Summary{}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestRenderStepSummary_ListsFindings(t *testing.T) {
+	var findings []Finding
+	for i := 0; i < stepSummaryMaxFindings+2; i++ {
+		findings = append(findings, Finding{Rule: "schema-invalid", Level: levelError, Message: "ConfigMap/a: data.x|y: expected string"})
+	}
+	got := renderStepSummary(Summary{Results: []RootResult{{Root: "apps/web", Status: statusSuccess, Findings: findings}}})
+
+	if !strings.Contains(got, "### Findings (52)") {
+		t.Errorf("expected findings heading, got:\n%s", got)
+	}
+	if !strings.Contains(got, "| `apps/web` | error | schema-invalid | ConfigMap/a: data.x\\|y: expected string |") {
+		t.Errorf("expected escaped finding row, got:\n%s", got)
+	}
+	if !strings.Contains(got, "…and 2 more findings") {
+		t.Errorf("expected truncation note, got:\n%s", got)
+	}
+}
+
+func TestRenderStepSummary_ChecksLine(t *testing.T) {
+	got := renderStepSummary(Summary{SchemaErrors: 2, PolicyViolations: 3})
+	if !strings.Contains(got, "**Checks:** 2 schema errors · 3 policy violations") {
+		t.Errorf("expected checks line, got:\n%s", got)
+	}
+	if strings.Contains(renderStepSummary(Summary{}), "**Checks:**") {
+		t.Error("expected no checks line without findings")
+	}
+}
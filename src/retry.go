@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// permanentBuildErrors mark failures that retrying cannot fix (broken YAML,
+// invalid kustomizations, missing files). They are checked before
+// retryableBuildErrors so that a YAML error is never retried.
+var permanentBuildErrors = []string{
+	"yaml: line ",
+	"yaml: unmarshal errors",
+	"json: cannot unmarshal",
+	"malformedyamlerror",
+	"error converting yaml",
+	"invalid kustomization",
+	"missing metadata",
+	"unknown field",
+	"no such file or directory",
+	"must build at directory",
+}
+
+// retryableBuildErrors mark transient network and registry failures, typically
+// from helm chart pulls or remote resources.
+var retryableBuildErrors = []*regexp.Regexp{
+	regexp.MustCompile(`i/o timeout`),
+	regexp.MustCompile(`tls handshake timeout`),
+	regexp.MustCompile(`timeout awaiting response headers`),
+	regexp.MustCompile(`client\.timeout exceeded`),
+	regexp.MustCompile(`connection reset`),
+	regexp.MustCompile(`connection refused`),
+	regexp.MustCompile(`broken pipe`),
+	regexp.MustCompile(`unexpected eof`),
+	regexp.MustCompile(`temporary failure in name resolution`),
+	regexp.MustCompile(`too many requests`),
+	regexp.MustCompile(`internal server error|bad gateway|service unavailable|gateway timeout`),
+	regexp.MustCompile(`\b(status|code|response)[^0-9\n]{0,20}(429|5\d\d)\b`),
+}
+
+// isRetryableBuildError classifies kustomize stderr as a transient failure.
+// Unknown errors are treated as permanent.
+func isRetryableBuildError(stderr string) bool {
+	s := strings.ToLower(stderr)
+	for _, p := range permanentBuildErrors {
+		if strings.Contains(s, p) {
+			return false
+		}
+	}
+	for _, re := range retryableBuildErrors {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxRetryDelay caps the exponential backoff between retries.
+const maxRetryDelay = time.Minute
+
+// maxBuildRetries is the largest accepted build-retries input.
+const maxBuildRetries = 10
+
+// retryDelay returns the exponential backoff before the retry following
+// attempt (1-based), capped at maxRetryDelay.
+func retryDelay(backoff time.Duration, attempt int) time.Duration {
+	if backoff <= 0 || attempt < 1 {
+		return 0
+	}
+	d := backoff
+	for i := 1; i < attempt && d < maxRetryDelay; i++ {
+		d *= 2
+	}
+	if d > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return d
+}
+
+// sleepContext waits for d and reports false if ctx ends first.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestIsRetryableBuildError(t *testing.T) {
+	tests := []struct {
+		name   string
+		stderr string
+		want   bool
+	}{
+		{"dial timeout", `Error: Get "https://charts.example.com/index.yaml": dial tcp 1.2.3.4:443: i/o timeout`, true},
+		{"connection reset", "read tcp 10.0.0.1:5555->1.2.3.4:443: read: connection reset by peer", true},
+		{"bad gateway", "failed to fetch https://charts.example.com/x.tgz : 502 Bad Gateway", true},
+		{"status code", "unexpected status code 503 from registry", true},
+		{"rate limited", "429 Too Many Requests", true},
+		{"yaml error", "Error: map[string]interface {}(nil): yaml: unmarshal errors:\n  line 3: cannot unmarshal", false},
+		{"missing file", "Error: accumulating resources: open /repo/app/missing.yaml: no such file or directory", false},
+		{"yaml error with timeout text", "yaml: line 2: did not find expected key (after i/o timeout)", false},
+		{"unknown", "something odd happened", false},
+		{"timeout fetching schema.yaml", `Error: Get "https://example.com/schema.yaml": net/http: TLS handshake timeout`, true},
+		{"timeout fetching yaml: url", "fetch yaml: https://charts.example.com/index.yaml: i/o timeout", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableBuildError(tt.stderr); got != tt.want {
+				t.Fatalf("isRetryableBuildError(%q) = %v, want %v", tt.stderr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	if got := retryDelay(time.Second, 1); got != time.Second {
+		t.Errorf("attempt 1: expected 1s, got %s", got)
+	}
+	if got := retryDelay(time.Second, 3); got != 4*time.Second {
+		t.Errorf("attempt 3: expected 4s, got %s", got)
+	}
+	if got := retryDelay(0, 3); got != 0 {
+		t.Errorf("zero backoff: expected 0, got %s", got)
+	}
+	for _, attempt := range []int{7, 30, 40, 100} {
+		if got := retryDelay(2*time.Second, attempt); got != maxRetryDelay {
+			t.Errorf("attempt %d: expected delay capped at %s, got %s", attempt, maxRetryDelay, got)
+		}
+	}
+}
+
+func TestSleepContext_StopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if sleepContext(ctx, time.Hour) {
+		t.Fatalf("expected sleep to be interrupted by a canceled context")
+	}
+}
@@ -44,6 +44,33 @@ func selectRootsForChangedFiles(roots []string, changedFiles []string) []string
 	return out
 }
 
+// selectRootsForChangedFilesWithDeps extends selectRootsForChangedFiles with every
+// root whose transitive kustomization inputs (bases, components, patches, ...)
+// include a changed file. Ordering follows roots.
+func selectRootsForChangedFilesWithDeps(roots []string, changedFiles []string, graph *dependencyGraph) []string {
+	selected := make(map[string]bool, len(roots))
+	for _, r := range selectRootsForChangedFiles(roots, changedFiles) {
+		selected[r] = true
+	}
+	if graph != nil {
+		for _, f := range changedFiles {
+			for _, r := range graph.rootsForFile(f) {
+				selected[r] = true
+			}
+		}
+	}
+
+	out := make([]string, 0, len(selected))
+	for _, r := range roots {
+		root := normalizeRepoRelativeDir(r)
+		if selected[root] {
+			out = append(out, root)
+			delete(selected, root)
+		}
+	}
+	return out
+}
+
 func rootPrefixesFile(root, file string) bool {
 	root = normalizeRepoRelativeDir(root)
 	file = normalizeRepoRelativePath(file)
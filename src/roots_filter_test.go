@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"path/filepath"
 	"testing"
 )
 
@@ -65,3 +66,30 @@ func TestSelectRootsForChangedFiles_DotRootMatchesEverything(t *testing.T) {
 		t.Fatalf("expected %v, got %v", expected, got)
 	}
 }
+
+func TestSelectRootsForChangedFilesWithDeps_SelectsConsumersOfSharedBase(t *testing.T) {
+	dir := t.TempDir()
+	mustWriteFile(t, filepath.Join(dir, "base/kustomization.yaml"), "resources:\n- deployment.yaml\n")
+	mustWriteFile(t, filepath.Join(dir, "base/deployment.yaml"), "kind: Deployment\n")
+	mustWriteFile(t, filepath.Join(dir, "overlay/kustomization.yaml"), "resources:\n- ../base\n")
+	mustWriteFile(t, filepath.Join(dir, "other/kustomization.yaml"), "resources: []\n")
+
+	roots := []string{"base", "other", "overlay"}
+	graph := buildDependencyGraph(dir, roots)
+
+	got := selectRootsForChangedFilesWithDeps(roots, []string{"base/deployment.yaml"}, graph)
+	expected := []string{"base", "overlay"}
+	if len(got) != len(expected) || got[0] != expected[0] || got[1] != expected[1] {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestSelectRootsForChangedFilesWithDeps_NilGraphMatchesPrefixSelection(t *testing.T) {
+	roots := []string{"apps", "apps/foo", "cluster"}
+	changed := []string{"apps/foo/deploy.yaml", "README.md"}
+	got := selectRootsForChangedFilesWithDeps(roots, changed, nil)
+	expected := selectRootsForChangedFiles(roots, changed)
+	if len(got) != len(expected) || got[0] != expected[0] {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	Level      string            `json:"level"`
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// renderSARIFReport converts the findings of every root, including build
+// failures, into a SARIF 2.1.0 log for GitHub code scanning.
+func renderSARIFReport(summary Summary) ([]byte, error) {
+	results := []sarifResult{}
+	used := map[string]bool{}
+	for _, r := range summary.Results {
+		for _, f := range buildFindings(r) {
+			file := f.File
+			if file == "" {
+				file = rootKustomizationFile(r.Root)
+			}
+			loc := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: file}}
+			if f.Line > 0 {
+				loc.Region = &sarifRegion{StartLine: f.Line}
+			}
+			props := map[string]string{"root": displayRoot(r.Root)}
+			if f.Resource != "" {
+				props["resource"] = f.Resource
+			}
+			results = append(results, sarifResult{
+				RuleID:     f.Rule,
+				Level:      f.Level,
+				Message:    sarifMessage{Text: f.Message},
+				Locations:  []sarifLocation{{PhysicalLocation: loc}},
+				Properties: props,
+			})
+			used[f.Rule] = true
+		}
+	}
+
+	rules := []sarifRule{}
+	for id := range used {
+		rules = append(rules, sarifRule{ID: id, ShortDescription: sarifMessage{Text: ruleDescription(id)}})
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+
+	report := sarifLog{
+		Schema:  sarifSchema,
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "kustomize-action",
+				InformationURI: "https://github.com/novog93/kustomize-action",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+	return json.MarshalIndent(report, "", "  ")
+}
+
+// writeSARIFReport writes the SARIF report to name inside outputDir.
+func writeSARIFReport(outputDir, name string, summary Summary) (string, error) {
+	b, err := renderSARIFReport(summary)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(outputDir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, b, 0o644)
+}
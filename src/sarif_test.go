@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenderSARIFReport(t *testing.T) {
+	chdirTemp(t)
+	mustWriteFile(t, filepath.Join("apps", "bad", "kustomization.yml"), "resources: [missing.yaml]\n")
+
+	summary := Summary{Results: []RootResult{
+		{Root: "apps/ok", Status: statusSuccess},
+		{Root: "apps/bad", Status: statusFailed, Stderr: "Error: accumulating resources from 'missing.yaml'\n"},
+		{Root: "apps/web", Status: statusSuccess, Findings: []Finding{
+			{Rule: "custom-check", Level: levelWarning, Message: "looks risky", File: "apps/web/deploy.yaml", Line: 7, Resource: "Deployment/default/web"},
+		}},
+	}}
+
+	b, err := renderSARIFReport(summary)
+	if err != nil {
+		t.Fatalf("renderSARIFReport: %v", err)
+	}
+
+	var got sarifLog
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got.Version != "2.1.0" || len(got.Runs) != 1 {
+		t.Fatalf("unexpected log header: %+v", got)
+	}
+	run := got.Runs[0]
+	if len(run.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(run.Results))
+	}
+
+	build := run.Results[0]
+	if build.RuleID != "build-failed" || build.Level != levelError {
+		t.Errorf("unexpected build result: %+v", build)
+	}
+	if uri := build.Locations[0].PhysicalLocation.ArtifactLocation.URI; uri != "apps/bad/kustomization.yml" {
+		t.Errorf("expected build failure at root kustomization file, got %s", uri)
+	}
+	if build.Properties["root"] != "apps/bad" {
+		t.Errorf("expected root property, got %v", build.Properties)
+	}
+
+	finding := run.Results[1]
+	loc := finding.Locations[0].PhysicalLocation
+	if loc.ArtifactLocation.URI != "apps/web/deploy.yaml" || loc.Region == nil || loc.Region.StartLine != 7 {
+		t.Errorf("unexpected finding location: %+v", loc)
+	}
+	if finding.Properties["resource"] != "Deployment/default/web" {
+		t.Errorf("expected resource property, got %v", finding.Properties)
+	}
+
+	if len(run.Tool.Driver.Rules) != 2 || run.Tool.Driver.Rules[0].ID != "build-failed" || run.Tool.Driver.Rules[1].ID != "custom-check" {
+		t.Errorf("unexpected rules: %+v", run.Tool.Driver.Rules)
+	}
+}
+
+func TestRenderSARIFReport_EmptyResultsIsArray(t *testing.T) {
+	b, err := renderSARIFReport(Summary{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(b, &raw); err != nil {
+		t.Fatal(err)
+	}
+	run := raw["runs"].([]any)[0].(map[string]any)
+	if _, ok := run["results"].([]any); !ok {
+		t.Errorf("expected results to be an empty array, got %v", run["results"])
+	}
+}
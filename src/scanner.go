@@ -1,7 +1,9 @@
 package main
 
 import (
+	"fmt"
 	"io/fs"
+	"log"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -12,6 +14,69 @@ func findKustomizationFiles(root string) ([]string, error) {
 }
 
 func findKustomizationFilesWithExclusions(root string, excludedDirs []string) ([]string, error) {
+	return findKustomizationFilesFiltered(root, excludedDirs, pathFilter{})
+}
+
+// pathFilter limits discovery to kustomization directories matching Include
+// (when non-empty) and not matching Exclude. Patterns are glob patterns with
+// "**" support, relative to the scanned directory.
+type pathFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// newPathFilter builds a pathFilter; "!"-prefixed include patterns are treated as exclusions.
+func newPathFilter(include, exclude []string) pathFilter {
+	var f pathFilter
+	for _, p := range include {
+		if strings.HasPrefix(p, "!") {
+			f.Exclude = append(f.Exclude, strings.TrimPrefix(p, "!"))
+			continue
+		}
+		f.Include = append(f.Include, p)
+	}
+	f.Exclude = append(f.Exclude, exclude...)
+	return f
+}
+
+// validateExcludePaths rejects "!"-prefixed exclude-paths entries. Exclusions
+// always win over inclusions, so a negation cannot re-include anything.
+func validateExcludePaths(exclude []string) error {
+	for _, p := range exclude {
+		if strings.HasPrefix(p, "!") {
+			return fmt.Errorf("negated pattern %q is not supported; narrow the exclusion instead", p)
+		}
+	}
+	return nil
+}
+
+// excludes reports whether the directory rel (and thus everything below it) is excluded.
+func (f pathFilter) excludes(rel string) bool {
+	for _, p := range f.Exclude {
+		if matchGlob(p, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// keeps reports whether a kustomization in directory rel should be discovered.
+func (f pathFilter) keeps(rel string) bool {
+	if f.excludes(rel) {
+		return false
+	}
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, p := range f.Include {
+		if matchGlob(p, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+func findKustomizationFilesFiltered(root string, excludedDirs []string, filter pathFilter) ([]string, error) {
 	excludedBase := make(map[string]struct{}, len(excludedDirs))
 	excludedRel := make(map[string]struct{}, len(excludedDirs))
 	for _, e := range excludedDirs {
@@ -34,6 +99,7 @@ func findKustomizationFilesWithExclusions(root string, excludedDirs []string) ([
 		}
 	}
 
+	ignore := newKustomizeIgnore()
 	var files []string
 	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -49,12 +115,25 @@ func findKustomizationFilesWithExclusions(root string, excludedDirs []string) ([
 				if _, ok := excludedRel[rel]; ok {
 					return fs.SkipDir
 				}
+				if filter.excludes(rel) {
+					return fs.SkipDir
+				}
+				if ignore.ignored(rel, true) {
+					log.Printf("🙈 Skipping %s (%s)", rel, kustomizeIgnoreFile)
+					return fs.SkipDir
+				}
 			}
+			ignore.load(path, rel)
 			return nil
 		}
 		base := filepath.Base(path)
-		if base == "kustomization.yaml" || base == "kustomization.yml" {
-			files = append(files, path)
+		if isKustomizationFileName(base) {
+			if ignore.ignored(relDir(root, path), false) {
+				return nil
+			}
+			if filter.keeps(relDir(root, filepath.Dir(path))) {
+				files = append(files, path)
+			}
 		}
 		return nil
 	})
@@ -97,6 +176,38 @@ func dedupeTopLevelDirs(paths []string) []string {
 	return keep
 }
 
+// dedupeReferencedDirs keeps the directories in paths that no kustomization in
+// referrers (or paths itself) references, regardless of directory nesting.
+// Directories are relative to base.
+func dedupeReferencedDirs(base string, paths, referrers []string) []string {
+	referenced := make(map[string]bool)
+	for _, dir := range append(append([]string{}, paths...), referrers...) {
+		d := normalizeRepoRelativeDir(dir)
+		kfile := kustomizationFileIn(filepath.Join(base, filepath.FromSlash(d)))
+		if kfile == "" {
+			continue
+		}
+		k, err := readKustomization(kfile)
+		if err != nil {
+			log.Printf("⚠️ Could not parse %s for root detection: %v", kfile, err)
+			continue
+		}
+		for _, ref := range k.localReferences() {
+			if p := joinRepoPath(d, ref); p != d {
+				referenced[p] = true
+			}
+		}
+	}
+
+	keep := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if !referenced[normalizeRepoRelativeDir(p)] {
+			keep = append(keep, p)
+		}
+	}
+	return keep
+}
+
 // relDir returns a normalized, slash-separated, trimmed directory path relative to base.
 func relDir(base, dir string) string {
 	rel, err := filepath.Rel(base, dir)
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 )
 
@@ -22,6 +23,7 @@ func TestFindKustomizationFiles(t *testing.T) {
 		"app1/kustomization.yaml",
 		"app2/kustomization.yml",
 		"app3/subdir/kustomization.yaml",
+		"app4/Kustomization",
 		"other/readme.md",
 	}
 
@@ -40,6 +42,7 @@ func TestFindKustomizationFiles(t *testing.T) {
 		filepath.Join(tmpDir, "app1/kustomization.yaml"),
 		filepath.Join(tmpDir, "app2/kustomization.yml"),
 		filepath.Join(tmpDir, "app3/subdir/kustomization.yaml"),
+		filepath.Join(tmpDir, "app4/Kustomization"),
 		filepath.Join(tmpDir, "kustomization.yaml"),
 	}
 	sort.Strings(expected)
@@ -217,3 +220,155 @@ func TestDedupeTopLevelDirs(t *testing.T) {
 		})
 	}
 }
+
+func TestFindKustomizationFilesFiltered_IncludeAndExclude(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := []string{
+		"clusters/prod/a/kustomization.yaml",
+		"clusters/prod/experimental/b/kustomization.yaml",
+		"clusters/staging/c/kustomization.yaml",
+		"apps/d/kustomization.yaml",
+		"apps/d/tests/kustomization.yaml",
+	}
+	for _, f := range files {
+		path := filepath.Join(tmpDir, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir for %s: %v", f, err)
+		}
+		if err := os.WriteFile(path, []byte(""), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", f, err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		filter   pathFilter
+		expected []string
+	}{
+		{
+			name:   "include with negation",
+			filter: newPathFilter([]string{"clusters/prod/**", "!**/experimental/**"}, nil),
+			expected: []string{
+				"clusters/prod/a/kustomization.yaml",
+			},
+		},
+		{
+			name:   "exclude only",
+			filter: newPathFilter(nil, []string{"clusters/**", "**/tests"}),
+			expected: []string{
+				"apps/d/kustomization.yaml",
+			},
+		},
+		{
+			name:   "no filter",
+			filter: pathFilter{},
+			expected: []string{
+				"apps/d/kustomization.yaml",
+				"apps/d/tests/kustomization.yaml",
+				"clusters/prod/a/kustomization.yaml",
+				"clusters/prod/experimental/b/kustomization.yaml",
+				"clusters/staging/c/kustomization.yaml",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := findKustomizationFilesFiltered(tmpDir, []string{".git"}, tt.filter)
+			if err != nil {
+				t.Fatalf("findKustomizationFilesFiltered returned error: %v", err)
+			}
+			var expected []string
+			for _, f := range tt.expected {
+				expected = append(expected, filepath.Join(tmpDir, f))
+			}
+			sort.Strings(expected)
+			if !reflect.DeepEqual(found, expected) {
+				t.Errorf("Expected %v, got %v", expected, found)
+			}
+		})
+	}
+}
+
+func TestValidateExcludePaths(t *testing.T) {
+	if err := validateExcludePaths([]string{"clusters/**", "**/tests"}); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := validateExcludePaths([]string{"apps/**", "!apps/keep"}); err == nil || !strings.Contains(err.Error(), `"!apps/keep"`) {
+		t.Errorf("expected negated exclude pattern to be rejected, got %v", err)
+	}
+}
+
+func TestFindKustomizationFiles_HonorsKustomizeIgnore(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		".kustomizeignore":                      "legacy/\n",
+		"legacy/a/kustomization.yaml":           "",
+		"apps/.kustomizeignore":                 "# opt out of CI rendering\nsandbox\n",
+		"apps/sandbox/kustomization.yaml":       "",
+		"apps/web/kustomization.yaml":           "",
+		"apps/web/.kustomizeignore":             "/*\n!/prod\n",
+		"apps/web/dev/kustomization.yaml":       "",
+		"apps/web/prod/kustomization.yaml":      "",
+		"other/sandbox/kustomization.yaml":      "",
+		"other/legacy-tools/kustomization.yaml": "",
+	}
+	for f, content := range files {
+		path := filepath.Join(tmpDir, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir for %s: %v", f, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", f, err)
+		}
+	}
+
+	found, err := findKustomizationFiles(tmpDir)
+	if err != nil {
+		t.Fatalf("findKustomizationFiles returned error: %v", err)
+	}
+
+	// "/*" in apps/web also matches apps/web/kustomization.yaml itself.
+	expected := []string{
+		filepath.Join(tmpDir, "apps/web/prod/kustomization.yaml"),
+		filepath.Join(tmpDir, "other/legacy-tools/kustomization.yaml"),
+		filepath.Join(tmpDir, "other/sandbox/kustomization.yaml"),
+	}
+	sort.Strings(expected)
+	if !reflect.DeepEqual(found, expected) {
+		t.Fatalf("Expected %v, got %v", expected, found)
+	}
+}
+
+func TestDedupeReferencedDirs(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"apps/kustomization.yaml":         "resources:\n- a\n- b\n",
+		"apps/a/kustomization.yaml":       "resources: []\n",
+		"apps/b/kustomization.yaml":       "resources:\n- ../../shared/base\n",
+		"apps/c/kustomization.yaml":       "resources: []\n",
+		"apps/d/kustomization.yaml":       "components:\n- ../../components/x\n",
+		"shared/base/kustomization.yaml":  "resources: []\n",
+		"components/x/kustomization.yaml": "kind: Component\nresources:\n- ../../shared/extra\n",
+		"shared/extra/kustomization.yaml": "resources: []\n",
+	}
+	for f, content := range files {
+		path := filepath.Join(tmpDir, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir for %s: %v", f, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", f, err)
+		}
+	}
+
+	candidates := []string{"apps", "apps/a", "apps/b", "apps/c", "apps/d", "shared/base", "shared/extra"}
+	got := dedupeReferencedDirs(tmpDir, candidates, []string{"components/x"})
+	expected := []string{"apps", "apps/c", "apps/d"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// schemaStore looks up the JSON schema of a Kubernetes kind. Schemas come
+// from CRDs found in the repository and from a local directory of vendored
+// schemas laid out like kubernetes-json-schema / kubeconform
+// ("<kind>-<group>-<version>.json", "<kind>-<version>.json" for the core
+// group) or the CRDs catalog ("<group>/<kind>_<version>.json").
+// Nothing is ever fetched over the network.
+type schemaStore struct {
+	dir string
+	crd map[string]map[string]interface{}
+
+	mu      sync.Mutex
+	files   map[string]map[string]interface{}
+	missing map[string]bool
+}
+
+// newSchemaStore indexes the CRDs under scanDir (skipping excludedDirs) and
+// prepares lazy loading of the vendored schemas in dir, which may be empty.
+// Run has already checked that dir is a directory.
+func newSchemaStore(dir, scanDir string, excludedDirs []string) (*schemaStore, error) {
+	s := &schemaStore{
+		dir:     dir,
+		crd:     map[string]map[string]interface{}{},
+		files:   map[string]map[string]interface{}{},
+		missing: map[string]bool{},
+	}
+	if scanDir != "" {
+		if err := s.loadCRDs(scanDir, excludedDirs); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// loadCRDs extracts the openAPIV3Schema of every version of every
+// CustomResourceDefinition in the YAML files under root.
+func (s *schemaStore) loadCRDs(root string, excludedDirs []string) error {
+	excluded := map[string]bool{}
+	for _, d := range excludedDirs {
+		if abs, err := filepath.Abs(filepath.Join(root, d)); err == nil {
+			excluded[abs] = true
+		}
+		if abs, err := filepath.Abs(d); err == nil {
+			excluded[abs] = true
+		}
+	}
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if abs, err := filepath.Abs(p); err == nil && excluded[abs] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(p))
+		if ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+		b, err := os.ReadFile(p)
+		if err != nil || !strings.Contains(string(b), "CustomResourceDefinition") {
+			return nil
+		}
+		docs, _ := parseManifests(b)
+		for _, doc := range docs {
+			if doc.Kind == "CustomResourceDefinition" {
+				s.addCRD(doc.Object)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *schemaStore) addCRD(obj map[string]interface{}) {
+	spec, _ := obj["spec"].(map[string]interface{})
+	group, _ := spec["group"].(string)
+	names, _ := spec["names"].(map[string]interface{})
+	kind, _ := names["kind"].(string)
+	if group == "" || kind == "" {
+		return
+	}
+	add := func(version string, validation interface{}) {
+		v, _ := validation.(map[string]interface{})
+		schema, ok := v["openAPIV3Schema"].(map[string]interface{})
+		if version == "" || !ok {
+			return
+		}
+		s.crd[schemaKey(group+"/"+version, kind)] = schema
+	}
+	versions, _ := spec["versions"].([]interface{})
+	for _, v := range versions {
+		vm, _ := v.(map[string]interface{})
+		name, _ := vm["name"].(string)
+		add(name, vm["schema"])
+		// apiextensions.k8s.io/v1beta1 allowed a schema shared by all versions.
+		if vm["schema"] == nil {
+			add(name, spec["validation"])
+		}
+	}
+	if version, _ := spec["version"].(string); version != "" {
+		add(version, spec["validation"])
+	}
+}
+
+// lookup returns the schema for apiVersion/kind and the file it was loaded
+// from ("" for CRD schemas). ok is false when no schema is known.
+func (s *schemaStore) lookup(apiVersion, kind string) (schema map[string]interface{}, file string, ok bool) {
+	key := schemaKey(apiVersion, kind)
+	if schema, ok := s.crd[key]; ok {
+		return schema, "", true
+	}
+	if s.dir == "" {
+		return nil, "", false
+	}
+	for _, name := range schemaFileCandidates(apiVersion, kind) {
+		if schema, err := s.loadFile(name); err == nil {
+			return schema, name, true
+		}
+	}
+	return nil, "", false
+}
+
+// noteMissing records that no schema exists for gvk and reports whether this is the first time.
+func (s *schemaStore) noteMissing(gvk string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.missing[gvk] {
+		return false
+	}
+	s.missing[gvk] = true
+	return true
+}
+
+// loadFile reads and caches a schema file by its slash-separated name inside dir.
+func (s *schemaStore) loadFile(name string) (map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if schema, ok := s.files[name]; ok {
+		if schema == nil {
+			return nil, os.ErrNotExist
+		}
+		return schema, nil
+	}
+	b, err := os.ReadFile(filepath.Join(s.dir, filepath.FromSlash(name)))
+	if err != nil {
+		s.files[name] = nil
+		return nil, err
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		s.files[name] = nil
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	s.files[name] = schema
+	return schema, nil
+}
+
+// resolveRef loads a $ref target relative to the schema file it appears in.
+func (s *schemaStore) resolveRef(fromFile, file string) (map[string]interface{}, string, error) {
+	if s.dir == "" {
+		return nil, "", fmt.Errorf("no schema-dir to resolve %s", file)
+	}
+	name := path.Clean(path.Join(path.Dir(fromFile), file))
+	if name == ".." || strings.HasPrefix(name, "../") {
+		return nil, "", fmt.Errorf("%s is outside schema-dir", file)
+	}
+	doc, err := s.loadFile(name)
+	return doc, name, err
+}
+
+func schemaKey(apiVersion, kind string) string {
+	return strings.ToLower(kind + "|" + apiVersion)
+}
+
+// schemaFileCandidates lists the file names a vendored schema for apiVersion/kind may have.
+func schemaFileCandidates(apiVersion, kind string) []string {
+	kind = strings.ToLower(kind)
+	group, version, found := strings.Cut(strings.ToLower(apiVersion), "/")
+	if !found {
+		// Core group, e.g. "v1".
+		return []string{kind + "-" + group + ".json"}
+	}
+	short, _, _ := strings.Cut(group, ".")
+	return []string{
+		kind + "-" + short + "-" + version + ".json",
+		kind + "-" + group + "-" + version + ".json",
+		group + "/" + kind + "_" + version + ".json",
+	}
+}
+
+// schemaChecker validates rendered manifests against the schemas of a schemaStore.
+type schemaChecker struct {
+	store *schemaStore
+}
+
+func (c *schemaChecker) checkManifests(root string, docs []manifest) []Finding {
+	var out []Finding
+	for _, doc := range docs {
+		if doc.APIVersion == "" || doc.Kind == "" {
+			out = append(out, Finding{Rule: "schema-invalid", Level: levelError, Message: "object is missing apiVersion or kind", Resource: doc.ResourceID()})
+			continue
+		}
+		schema, file, ok := c.store.lookup(doc.APIVersion, doc.Kind)
+		if !ok {
+			if c.store.noteMissing(doc.GVK()) {
+				log.Printf("ℹ️ No schema for %s; its objects are not validated.", doc.GVK())
+			}
+			continue
+		}
+		for _, e := range validateAgainstSchema(doc.Object, schema, file, schema, c.store.resolveRef) {
+			out = append(out, Finding{
+				Rule:     "schema-invalid",
+				Level:    levelError,
+				Message:  fmt.Sprintf("%s: %s", doc.ResourceID(), e),
+				Resource: doc.ResourceID(),
+			})
+		}
+	}
+	return out
+}
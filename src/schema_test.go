@@ -0,0 +1,112 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const widgetCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+  versions:
+  - name: v1
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            required: [size]
+            properties:
+              size: {type: integer}
+`
+
+func TestSchemaStore_LoadsCRDsAndVendoredSchemas(t *testing.T) {
+	repo := t.TempDir()
+	mustWriteFile(t, filepath.Join(repo, "crds", "widget.yaml"), "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: x\n---\n"+widgetCRD)
+	// CRDs in excluded directories (e.g. the output dir) are ignored.
+	mustWriteFile(t, filepath.Join(repo, "out", "gadget.yaml"), strings.ReplaceAll(widgetCRD, "Widget", "Gadget"))
+
+	schemas := t.TempDir()
+	mustWriteFile(t, filepath.Join(schemas, "deployment-apps-v1.json"), `{"type":"object","properties":{"spec":{"$ref":"_definitions.json#/definitions/spec"}}}`)
+	mustWriteFile(t, filepath.Join(schemas, "_definitions.json"), `{"definitions":{"spec":{"type":"object","properties":{"replicas":{"type":"integer"}}}}}`)
+	mustWriteFile(t, filepath.Join(schemas, "configmap-v1.json"), `{"type":"object"}`)
+	mustWriteFile(t, filepath.Join(schemas, "monitoring.coreos.com", "servicemonitor_v1.json"), `{"type":"object"}`)
+
+	store, err := newSchemaStore(schemas, repo, []string{".git", "out"})
+	if err != nil {
+		t.Fatalf("newSchemaStore: %v", err)
+	}
+
+	for _, tc := range []struct {
+		apiVersion, kind string
+		want             bool
+	}{
+		{"example.com/v1", "Widget", true},
+		{"example.com/v1", "Gadget", false},
+		{"apps/v1", "Deployment", true},
+		{"v1", "ConfigMap", true},
+		{"monitoring.coreos.com/v1", "ServiceMonitor", true},
+		{"v1", "Secret", false},
+	} {
+		if _, _, ok := store.lookup(tc.apiVersion, tc.kind); ok != tc.want {
+			t.Errorf("lookup(%s, %s) = %v, want %v", tc.apiVersion, tc.kind, ok, tc.want)
+		}
+	}
+
+	checker := &schemaChecker{store: store}
+	docs, err := parseManifests([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web, namespace: prod}
+spec: {replicas: "3"}
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata: {name: w}
+spec: {}
+---
+apiVersion: v1
+kind: Secret
+metadata: {name: s}
+---
+metadata: {name: nokind}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := checker.checkManifests("apps/web", docs)
+
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Resource+" | "+f.Message)
+		if f.Rule != "schema-invalid" || f.Level != levelError {
+			t.Errorf("unexpected rule/level: %+v", f)
+		}
+	}
+	want := []string{
+		"Deployment/prod/web | Deployment/prod/web: spec.replicas: expected integer, got string",
+		`Widget/w | Widget/w: spec: missing required field "size"`,
+		"/nokind | object is missing apiVersion or kind",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestSchemaFileCandidates(t *testing.T) {
+	got := schemaFileCandidates("networking.k8s.io/v1", "NetworkPolicy")
+	want := []string{
+		"networkpolicy-networking-v1.json",
+		"networkpolicy-networking.k8s.io-v1.json",
+		"networking.k8s.io/networkpolicy_v1.json",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}